@@ -1,7 +1,9 @@
 package ast
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/goptos/stateparser/ast/nodes"
 	"github.com/goptos/stateparser/lexer"
@@ -11,39 +13,57 @@ import (
 
 var verbose = (*utils.Verbose).New(nil)
 
+// SkipChildren is returned by a StartElementNodeProcessor or a
+// ComponentNodeProcessor that handled the whole subtree of its node, its
+// children and end element are not processed.
+var SkipChildren = errors.New("skip children")
+
 type Ast struct {
-	keywordAttributeNames          map[string]interface{}
-	Lexer                          *lexer.Lexer
-	Root                           nodes.Node
-	StartElementNodeProcessor      func(node *nodes.StartElementNode, depth *int) error
-	ComponentNodeProcessor         func(node *nodes.ComponentNode, depth *int) error
-	EndElementNodeProcessor        func(node *nodes.EndElementNode, depth *int) error
-	CommentNodeProcessor           func(node *nodes.CommentNode, depth *int) error
-	TextNodeProcessor              func(node *nodes.TextNode, depth *int) error
-	DynTextNodeProcessor           func(node *nodes.DynTextNode, depth *int) error
-	AttributeNodeProcessor         func(node *nodes.AttributeNode, depth *int) error
-	ArgumentAttributeNodeProcessor func(node *nodes.ArgumentAttributeNode, depth *int) error
-	DynAttributeNodeProcessor      func(node *nodes.DynAttributeNode, depth *int) error
-	EventAttributeNodeProcessor    func(node *nodes.EventAttributeNode, depth *int) error
-	KeywordAttributeNodeProcessor  func(node *nodes.KeywordAttributeNode, depth *int) error
+	keywordAttributeNames            map[string]interface{}
+	Lexer                            *lexer.Lexer
+	Root                             nodes.Node
+	StartElementNodeProcessor        func(node *nodes.StartElementNode, depth *int) error
+	ComponentNodeProcessor           func(node *nodes.ComponentNode, depth *int) error
+	EndElementNodeProcessor          func(node *nodes.EndElementNode, depth *int) error
+	CommentNodeProcessor             func(node *nodes.CommentNode, depth *int) error
+	TextNodeProcessor                func(node *nodes.TextNode, depth *int) error
+	DynTextNodeProcessor             func(node *nodes.DynTextNode, depth *int) error
+	AttributeNodeProcessor           func(node *nodes.AttributeNode, depth *int) error
+	ArgumentAttributeNodeProcessor   func(node *nodes.ArgumentAttributeNode, depth *int) error
+	DynAttributeNodeProcessor        func(node *nodes.DynAttributeNode, depth *int) error
+	EventAttributeNodeProcessor      func(node *nodes.EventAttributeNode, depth *int) error
+	KeywordAttributeNodeProcessor    func(node *nodes.KeywordAttributeNode, depth *int) error
+	ExpressionAttributeNodeProcessor func(node *nodes.ExpressionAttributeNode, depth *int) error
+	SpreadAttributeNodeProcessor     func(node *nodes.SpreadAttributeNode, depth *int) error
 }
 
 func New(source string) *Ast {
+	return newAst(lexer.New(source))
+}
+
+// NewReader returns an Ast built from the source read from r.
+func NewReader(r io.Reader) *Ast {
+	return newAst(lexer.NewReader(r))
+}
+
+func newAst(l *lexer.Lexer) *Ast {
 	return &Ast{
-		keywordAttributeNames:          make(map[string]interface{}),
-		Lexer:                          lexer.New(source),
-		Root:                           nil,
-		StartElementNodeProcessor:      (*nodes.StartElementNode).Print,
-		ComponentNodeProcessor:         (*nodes.ComponentNode).Print,
-		EndElementNodeProcessor:        (*nodes.EndElementNode).Print,
-		CommentNodeProcessor:           (*nodes.CommentNode).Print,
-		TextNodeProcessor:              (*nodes.TextNode).Print,
-		DynTextNodeProcessor:           (*nodes.DynTextNode).Print,
-		AttributeNodeProcessor:         (*nodes.AttributeNode).Print,
-		ArgumentAttributeNodeProcessor: (*nodes.ArgumentAttributeNode).Print,
-		DynAttributeNodeProcessor:      (*nodes.DynAttributeNode).Print,
-		EventAttributeNodeProcessor:    (*nodes.EventAttributeNode).Print,
-		KeywordAttributeNodeProcessor:  (*nodes.KeywordAttributeNode).Print}
+		keywordAttributeNames:            make(map[string]interface{}),
+		Lexer:                            l,
+		Root:                             nil,
+		StartElementNodeProcessor:        (*nodes.StartElementNode).Print,
+		ComponentNodeProcessor:           (*nodes.ComponentNode).Print,
+		EndElementNodeProcessor:          (*nodes.EndElementNode).Print,
+		CommentNodeProcessor:             (*nodes.CommentNode).Print,
+		TextNodeProcessor:                (*nodes.TextNode).Print,
+		DynTextNodeProcessor:             (*nodes.DynTextNode).Print,
+		AttributeNodeProcessor:           (*nodes.AttributeNode).Print,
+		ArgumentAttributeNodeProcessor:   (*nodes.ArgumentAttributeNode).Print,
+		DynAttributeNodeProcessor:        (*nodes.DynAttributeNode).Print,
+		EventAttributeNodeProcessor:      (*nodes.EventAttributeNode).Print,
+		KeywordAttributeNodeProcessor:    (*nodes.KeywordAttributeNode).Print,
+		ExpressionAttributeNodeProcessor: (*nodes.ExpressionAttributeNode).Print,
+		SpreadAttributeNodeProcessor:     (*nodes.SpreadAttributeNode).Print}
 }
 
 func (_self *Ast) AddKeywordAttributeName(s string) {
@@ -51,23 +71,27 @@ func (_self *Ast) AddKeywordAttributeName(s string) {
 	_self.Lexer.KeywordAttributeNames[s] = nil
 }
 
+// Create builds the tree of the first element or component in the source
+// while the lexer reads it. The rest of the source is still lexed so errors
+// after the root are reported.
 func (_self *Ast) Create() error {
-	err := _self.Lexer.Tokenise()
-	if err != nil {
-		return err
-	}
 	verbose.Printf(3, "::: Ast.Create() :::\n")
-	for i := 0; i < len(_self.Lexer.Tokens); i++ {
-		if _self.Lexer.Tokens[i].GetType() != tokens.StartTag {
+	for {
+		token, err := _self.Lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _self.Root != nil || token.GetType() != tokens.StartTag {
 			continue
 		}
-		var index = i
-		root, err := _self.createR(&index)
+		root, err := _self.createR(token)
 		if err != nil {
 			return err
 		}
 		_self.Root = root
-		break
 	}
 	if _self.Root == nil {
 		return fmt.Errorf("must be a HTML element or a Component")
@@ -75,50 +99,67 @@ func (_self *Ast) Create() error {
 	return nil
 }
 
-func (_self *Ast) createR(index *int) (nodes.Node, error) {
-	verbose.Printf(3, "%d\t%s (%s)\n", *index, _self.Lexer.Tokens[*index].GetName(), _self.Lexer.Tokens[*index].GetType())
-	utils.Assert(
-		_self.Lexer.Tokens[*index].GetType() == tokens.StartTag,
-		"token is a tokens.StartTag type",
-		2)
-	var ambiguousRootNode = nodes.NewAmbiguousRootNode(_self.Lexer.Tokens[*index])
-	*index++
+func (_self *Ast) createR(startTag tokens.Token) (nodes.Node, error) {
+	verbose.Printf(3, "%s (%s)\n", startTag.GetName(), startTag.GetType())
+	if startTag.GetType() != tokens.StartTag {
+		return nil, fmt.Errorf("token is a %s, not a %s", startTag.GetType(), tokens.StartTag)
+	}
+	var ambiguousRootNode = nodes.NewAmbiguousRootNode(startTag)
 	if ambiguousRootNode.GetIsSelfClosing() {
 		return ambiguousRootNode, nil
 	}
-	for *index < len(_self.Lexer.Tokens) {
-		var token = _self.Lexer.Tokens[*index]
+	for {
+		token, err := _self.Lexer.Next()
+		if err == io.EOF {
+			return nil, &tokens.Error{
+				Position: startTag.GetPosition(),
+				Err:      fmt.Errorf("<%s> is not closed", startTag.GetName())}
+		}
+		if err != nil {
+			return nil, err
+		}
+		var child nodes.Node
 		switch token.GetType() {
 		case tokens.StartTag:
-			var child, err = _self.createR(index)
+			child, err = _self.createR(token)
 			if err != nil {
 				return nil, err
 			}
-			ambiguousRootNode.AppendToChildren(child)
 		case tokens.EndTag:
-			ambiguousRootNode.AppendToChildren(nodes.NewEndElementNode(token, ambiguousRootNode))
-			*index++
+			if token.GetName() != startTag.GetName() && lexer.IsVoidElement(token.GetName()) {
+				continue
+			}
+			if token.GetName() != startTag.GetName() {
+				return nil, &tokens.Error{
+					Position: token.GetPosition(),
+					Err:      fmt.Errorf("</%s> does not close <%s>", token.GetName(), startTag.GetName())}
+			}
+			if err := ambiguousRootNode.AppendToChildren(nodes.NewEndElementNode(token, ambiguousRootNode)); err != nil {
+				return nil, err
+			}
 			return ambiguousRootNode, nil
 		case tokens.Comment:
-			ambiguousRootNode.AppendToChildren(nodes.NewCommentNode(token))
-			*index++
+			child = nodes.NewCommentNode(token)
 		case tokens.Text:
-			ambiguousRootNode.AppendToChildren(nodes.NewTextNode(token))
-			*index++
+			child = nodes.NewTextNode(token)
 		case tokens.Code:
-			ambiguousRootNode.AppendToChildren(nodes.NewDynTextNode(token))
-			*index++
+			child = nodes.NewDynTextNode(token)
 		case tokens.EndOfFile:
-			*index++
+			continue
 		default:
 			return nil, fmt.Errorf("unknown TokenType %q", token.GetType())
 		}
+		if err := ambiguousRootNode.AppendToChildren(child); err != nil {
+			return nil, err
+		}
 	}
-	return ambiguousRootNode, nil
 }
 
 func (_self *Ast) Process() error {
 	verbose.Printf(2, "::: Ast.Process() :::\n")
+	if _self.Root == nil {
+		return fmt.Errorf("must be a HTML element or a Component")
+	}
 	var depth = 0
 	err := _self.processR(_self.Root, &depth)
 	if err != nil {
@@ -127,23 +168,35 @@ func (_self *Ast) Process() error {
 	return nil
 }
 
+// positionError places an error returned by a processor at node, unless it
+// already has a position.
+func positionError(node nodes.Node, err error) error {
+	var positioned *tokens.Error
+	if errors.As(err, &positioned) {
+		return err
+	}
+	return &tokens.Error{Position: node.GetPosition(), Err: err}
+}
+
 func (_self *Ast) processR(ambiguousNode nodes.Node, depth *int) error {
-	utils.Assert(
-		ambiguousNode.GetType() == nodes.StartElement ||
-			ambiguousNode.GetType() == nodes.Component,
-		"current node is either a nodes.StartElement or a nodes.Component type",
-		2)
+	if ambiguousNode.GetType() != nodes.StartElement && ambiguousNode.GetType() != nodes.Component {
+		return fmt.Errorf("node is a %s, not a %s or a %s", ambiguousNode.GetType(), nodes.StartElement, nodes.Component)
+	}
+	var err error
 	switch ambiguousNode.GetType() {
 	case nodes.StartElement:
-		err := _self.StartElementNodeProcessor(ambiguousNode.(*nodes.StartElementNode), depth)
-		if err != nil {
-			return err
-		}
+		err = _self.StartElementNodeProcessor(ambiguousNode.(*nodes.StartElementNode), depth)
 	case nodes.Component:
-		err := _self.ComponentNodeProcessor(ambiguousNode.(*nodes.ComponentNode), depth)
-		if err != nil {
-			return err
+		err = _self.ComponentNodeProcessor(ambiguousNode.(*nodes.ComponentNode), depth)
+	}
+	if err == SkipChildren {
+		if !ambiguousNode.GetIsSelfClosing() {
+			*depth--
 		}
+		return nil
+	}
+	if err != nil {
+		return positionError(ambiguousNode, err)
 	}
 	for i := 0; i < len(ambiguousNode.GetChildren()); i++ {
 		var node = ambiguousNode.GetChildren()[i]
@@ -152,7 +205,7 @@ func (_self *Ast) processR(ambiguousNode nodes.Node, depth *int) error {
 			*depth++
 			err := _self.processR(node.(*nodes.StartElementNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 			if node.GetIsSelfClosing() {
 				*depth--
@@ -161,7 +214,7 @@ func (_self *Ast) processR(ambiguousNode nodes.Node, depth *int) error {
 			*depth++
 			err := _self.processR(node.(*nodes.ComponentNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 			if node.GetIsSelfClosing() {
 				*depth--
@@ -169,49 +222,65 @@ func (_self *Ast) processR(ambiguousNode nodes.Node, depth *int) error {
 		case nodes.EndElement:
 			err := _self.EndElementNodeProcessor(node.(*nodes.EndElementNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 			*depth--
 		case nodes.Comment:
 			err := _self.CommentNodeProcessor(node.(*nodes.CommentNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.Text:
 			err := _self.TextNodeProcessor(node.(*nodes.TextNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.DynText:
 			err := _self.DynTextNodeProcessor(node.(*nodes.DynTextNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.Attribute:
 			err := _self.AttributeNodeProcessor(node.(*nodes.AttributeNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.ArgumentAttribute:
 			err := _self.ArgumentAttributeNodeProcessor(node.(*nodes.ArgumentAttributeNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.DynAttribute:
 			err := _self.DynAttributeNodeProcessor(node.(*nodes.DynAttributeNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.EventAttribute:
 			err := _self.EventAttributeNodeProcessor(node.(*nodes.EventAttributeNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
 			}
 		case nodes.KeywordAttribute:
 			err := _self.KeywordAttributeNodeProcessor(node.(*nodes.KeywordAttributeNode), depth)
 			if err != nil {
-				return err
+				return positionError(node, err)
+			}
+		case nodes.ExpressionAttribute:
+			err := _self.ExpressionAttributeNodeProcessor(node.(*nodes.ExpressionAttributeNode), depth)
+			if err != nil {
+				return positionError(node, err)
 			}
+		case nodes.SpreadAttribute:
+			err := _self.SpreadAttributeNodeProcessor(node.(*nodes.SpreadAttributeNode), depth)
+			if err != nil {
+				return positionError(node, err)
+			}
+		}
+	}
+	if ambiguousNode.GetIsSelfClosing() {
+		err := _self.EndElementNodeProcessor(nodes.NewImplicitEndElementNode(ambiguousNode), depth)
+		if err != nil {
+			return positionError(ambiguousNode, err)
 		}
 	}
 	return nil
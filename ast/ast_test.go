@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/goptos/stateparser/ast/nodes"
+)
+
+// FuzzCreate checks that building the tree of any source returns an error
+// rather than panicking, or a tree whose elements are closed by their own
+// end tag.
+func FuzzCreate(f *testing.F) {
+	for _, seed := range []string{
+		"<div></div>",
+		`<div id="a" hidden>text { code }<!-- comment --></div>`,
+		`<ul each={ items } key={ item.ID }><li class:done={ d } on:click={ f }>{ item }</li></ul>`,
+		`<Button label="ok" { ...props } if={ show } />`,
+		"<div><p></div>",
+		"text <div/> <p>",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		var ast = New(source)
+		ast.AddKeywordAttributeName("if")
+		ast.AddKeywordAttributeName("each")
+		ast.AddKeywordAttributeName("key")
+		err := ast.Create()
+		if err != nil {
+			return
+		}
+		if ast.Root == nil {
+			t.Fatal("no error and no root")
+		}
+		checkClosed(t, ast.Root)
+	})
+}
+
+// checkClosed checks that node and its descendants, unless self-closing, end
+// with the end tag of the same name.
+func checkClosed(t *testing.T, node nodes.Node) {
+	t.Helper()
+	if node.GetType() != nodes.StartElement && node.GetType() != nodes.Component {
+		return
+	}
+	var children = node.GetChildren()
+	if !node.GetIsSelfClosing() {
+		if len(children) == 0 {
+			t.Fatalf("<%s> has no end tag", node.GetName())
+		}
+		var end = children[len(children)-1]
+		if end.GetType() != nodes.EndElement || end.GetName() != node.GetName() || end.GetStartElementNode() != node {
+			t.Fatalf("<%s> ends with a %s %s", node.GetName(), end.GetType(), end.GetName())
+		}
+	}
+	for _, child := range children {
+		checkClosed(t, child)
+	}
+}
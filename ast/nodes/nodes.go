@@ -1,6 +1,7 @@
 package nodes
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/goptos/stateparser/lexer/tokens"
@@ -11,18 +12,26 @@ var verbose = (*utils.Verbose).New(nil)
 
 type NodeType string
 
+// noChildren reports an attempt to nest a node under one that cannot have
+// children.
+func noChildren(nodeType NodeType) error {
+	return fmt.Errorf("%s node has no children", nodeType)
+}
+
 const (
-	StartElement      NodeType = "StartElement"
-	Component         NodeType = "Component"
-	EndElement        NodeType = "EndElement"
-	Comment           NodeType = "Comment"
-	Text              NodeType = "Text"
-	DynText           NodeType = "DynText"
-	Attribute         NodeType = "Attribute"
-	ArgumentAttribute NodeType = "ArgumentAttribute"
-	DynAttribute      NodeType = "DynAttribute"
-	EventAttribute    NodeType = "EventAttribute"
-	KeywordAttribute  NodeType = "KeywordAttribute"
+	StartElement        NodeType = "StartElement"
+	Component           NodeType = "Component"
+	EndElement          NodeType = "EndElement"
+	Comment             NodeType = "Comment"
+	Text                NodeType = "Text"
+	DynText             NodeType = "DynText"
+	Attribute           NodeType = "Attribute"
+	ArgumentAttribute   NodeType = "ArgumentAttribute"
+	DynAttribute        NodeType = "DynAttribute"
+	EventAttribute      NodeType = "EventAttribute"
+	KeywordAttribute    NodeType = "KeywordAttribute"
+	ExpressionAttribute NodeType = "ExpressionAttribute"
+	SpreadAttribute     NodeType = "SpreadAttribute"
 )
 
 type StartElementNode struct {
@@ -30,6 +39,7 @@ type StartElementNode struct {
 	name          string
 	children      []Node
 	isSelfClosing bool
+	position      tokens.Position
 }
 
 type ComponentNode struct {
@@ -37,58 +47,82 @@ type ComponentNode struct {
 	name          string
 	children      []Node
 	isSelfClosing bool
+	position      tokens.Position
 }
 
 type EndElementNode struct {
 	_type         NodeType
 	name          string
 	startElemNode Node
+	position      tokens.Position
 }
 
 type CommentNode struct {
-	_type NodeType
-	data  string
+	_type    NodeType
+	data     string
+	position tokens.Position
 }
 
 type TextNode struct {
-	_type NodeType
-	data  string
+	_type    NodeType
+	data     string
+	position tokens.Position
 }
 
 type DynTextNode struct {
-	_type  NodeType
-	effect string
+	_type    NodeType
+	effect   string
+	position tokens.Position
 }
 
 type AttributeNode struct {
-	_type NodeType
-	name  string
-	value string
+	_type    NodeType
+	name     string
+	value    string
+	position tokens.Position
 }
 
 type ArgumentAttributeNode struct {
-	_type NodeType
-	name  string
+	_type    NodeType
+	name     string
+	position tokens.Position
 }
 
 type DynAttributeNode struct {
-	_type  NodeType
-	name   string
-	value  string
-	effect string
+	_type    NodeType
+	name     string
+	value    string
+	effect   string
+	position tokens.Position
 }
 
 type EventAttributeNode struct {
-	_type  NodeType
-	name   string
-	event  string
-	effect string
+	_type     NodeType
+	name      string
+	event     string
+	modifiers []string
+	effect    string
+	position  tokens.Position
 }
 
 type KeywordAttributeNode struct {
-	_type  NodeType
-	name   string
-	effect string
+	_type    NodeType
+	name     string
+	effect   string
+	position tokens.Position
+}
+
+type ExpressionAttributeNode struct {
+	_type    NodeType
+	name     string
+	effect   string
+	position tokens.Position
+}
+
+type SpreadAttributeNode struct {
+	_type    NodeType
+	effect   string
+	position tokens.Position
 }
 
 func NewAmbiguousRootNode(token tokens.Token) Node {
@@ -110,6 +144,10 @@ func NewStartElementNode(token tokens.Token) *StartElementNode {
 			children = append(children, NewDynAttributeNode(&attribute))
 		case tokens.KeywordAttribute:
 			children = append(children, NewKeywordAttributeNode(&attribute))
+		case tokens.ExpressionAttribute:
+			children = append(children, NewExpressionAttributeNode(&attribute))
+		case tokens.SpreadAttribute:
+			children = append(children, NewSpreadAttributeNode(&attribute))
 		default:
 			children = append(children, NewAttributeNode(&attribute))
 		}
@@ -118,19 +156,26 @@ func NewStartElementNode(token tokens.Token) *StartElementNode {
 		_type:         StartElement,
 		name:          token.GetName(),
 		children:      children,
-		isSelfClosing: token.GetIsSelfClosing()}
+		isSelfClosing: token.GetIsSelfClosing(),
+		position:      token.GetPosition()}
 }
 
 func NewComponentNode(token tokens.Token) *ComponentNode {
 	var children = []Node{}
 	for _, attribute := range token.GetAttributes() {
 		switch attribute.Type {
+		case tokens.ArgumentAttribute:
+			children = append(children, NewArgumentAttributeNode(&attribute))
 		case tokens.EventAttribute:
 			children = append(children, NewEventAttributeNode(&attribute))
 		case tokens.DynamicAttribute:
 			children = append(children, NewDynAttributeNode(&attribute))
 		case tokens.KeywordAttribute:
 			children = append(children, NewKeywordAttributeNode(&attribute))
+		case tokens.ExpressionAttribute:
+			children = append(children, NewExpressionAttributeNode(&attribute))
+		case tokens.SpreadAttribute:
+			children = append(children, NewSpreadAttributeNode(&attribute))
 		default:
 			children = append(children, NewAttributeNode(&attribute))
 		}
@@ -139,32 +184,47 @@ func NewComponentNode(token tokens.Token) *ComponentNode {
 		_type:         Component,
 		name:          token.GetName(),
 		children:      children,
-		isSelfClosing: token.GetIsSelfClosing()}
+		isSelfClosing: token.GetIsSelfClosing(),
+		position:      token.GetPosition()}
 }
 
 func NewEndElementNode(token tokens.Token, node Node) *EndElementNode {
 	return &EndElementNode{
 		_type:         EndElement,
 		name:          token.GetName(),
-		startElemNode: node}
+		startElemNode: node,
+		position:      token.GetPosition()}
+}
+
+// NewImplicitEndElementNode closes a self-closing element or component once
+// its attributes have been processed.
+func NewImplicitEndElementNode(node Node) *EndElementNode {
+	return &EndElementNode{
+		_type:         EndElement,
+		name:          node.GetName(),
+		startElemNode: node,
+		position:      node.GetPosition()}
 }
 
 func NewCommentNode(token tokens.Token) *CommentNode {
 	return &CommentNode{
-		_type: Comment,
-		data:  token.GetData()}
+		_type:    Comment,
+		data:     token.GetData(),
+		position: token.GetPosition()}
 }
 
 func NewTextNode(token tokens.Token) *TextNode {
 	return &TextNode{
-		_type: Text,
-		data:  token.GetData()}
+		_type:    Text,
+		data:     token.GetData(),
+		position: token.GetPosition()}
 }
 
 func NewDynTextNode(token tokens.Token) *DynTextNode {
 	return &DynTextNode{
-		_type:  DynText,
-		effect: token.GetData()}
+		_type:    DynText,
+		effect:   token.GetData(),
+		position: token.GetPosition()}
 }
 
 func NewAttributeNode(attribute *tokens.Attribute) *AttributeNode {
@@ -177,34 +237,60 @@ func NewAttributeNode(attribute *tokens.Attribute) *AttributeNode {
 
 func NewArgumentAttributeNode(attribute *tokens.Attribute) *ArgumentAttributeNode {
 	return &ArgumentAttributeNode{
-		_type: ArgumentAttribute,
-		name:  attribute.Name,
+		_type:    ArgumentAttribute,
+		name:     attribute.Name,
+		position: attribute.NamePosition,
 	}
 }
 
 func NewDynAttributeNode(attribute *tokens.Attribute) *DynAttributeNode {
+	var name, value, _ = strings.Cut(attribute.Name, ":")
 	return &DynAttributeNode{
-		_type:  DynAttribute,
-		name:   strings.Split(attribute.Name, ":")[0],
-		value:  strings.Split(attribute.Name, ":")[1],
-		effect: attribute.Value,
+		_type:    DynAttribute,
+		name:     name,
+		value:    value,
+		effect:   attribute.Value,
+		position: attribute.NamePosition,
 	}
 }
 
 func NewEventAttributeNode(attribute *tokens.Attribute) *EventAttributeNode {
+	var name, event, _ = strings.Cut(attribute.Name, ":")
+	var modifiers = strings.Split(event, "|")
 	return &EventAttributeNode{
-		_type:  EventAttribute,
-		name:   strings.Split(attribute.Name, ":")[0],
-		event:  strings.Split(attribute.Name, ":")[1],
-		effect: attribute.Value,
+		_type:     EventAttribute,
+		name:      name,
+		event:     modifiers[0],
+		modifiers: modifiers[1:],
+		effect:    attribute.Value,
+		position:  attribute.NamePosition,
 	}
 }
 
 func NewKeywordAttributeNode(attribute *tokens.Attribute) *KeywordAttributeNode {
 	return &KeywordAttributeNode{
-		_type:  KeywordAttribute,
-		name:   attribute.Name,
-		effect: attribute.Value,
+		_type:    KeywordAttribute,
+		name:     attribute.Name,
+		effect:   attribute.Value,
+		position: attribute.NamePosition,
+	}
+}
+
+func NewExpressionAttributeNode(attribute *tokens.Attribute) *ExpressionAttributeNode {
+	return &ExpressionAttributeNode{
+		_type:    ExpressionAttribute,
+		name:     attribute.Name,
+		effect:   attribute.Value,
+		position: attribute.NamePosition,
+	}
+}
+
+// `{...attrs}` => effect `attrs`
+func NewSpreadAttributeNode(attribute *tokens.Attribute) *SpreadAttributeNode {
+	return &SpreadAttributeNode{
+		_type:    SpreadAttribute,
+		effect:   strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(attribute.Value), "...")),
+		position: attribute.NamePosition,
 	}
 }
 
@@ -217,8 +303,10 @@ type Node interface {
 	GetEffect() string
 	GetValue() string
 	GetEvent() string
+	GetModifiers() []string
+	GetPosition() tokens.Position
 	GetIsSelfClosing() bool
-	AppendToChildren(Node)
+	AppendToChildren(Node) error
 	Print(*int) error
 }
 
@@ -268,6 +356,14 @@ func (_self *KeywordAttributeNode) GetType() NodeType {
 	return _self._type
 }
 
+func (_self *ExpressionAttributeNode) GetType() NodeType {
+	return _self._type
+}
+
+func (_self *SpreadAttributeNode) GetType() NodeType {
+	return _self._type
+}
+
 // GetName()
 
 func (_self *StartElementNode) GetName() string {
@@ -283,17 +379,14 @@ func (_self *EndElementNode) GetName() string {
 }
 
 func (_self *CommentNode) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 func (_self *TextNode) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 func (_self *DynTextNode) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
@@ -317,6 +410,14 @@ func (_self *KeywordAttributeNode) GetName() string {
 	return _self.name
 }
 
+func (_self *ExpressionAttributeNode) GetName() string {
+	return _self.name
+}
+
+func (_self *SpreadAttributeNode) GetName() string {
+	return ""
+}
+
 // GetChildren()
 
 func (_self *StartElementNode) GetChildren() []Node {
@@ -328,59 +429,56 @@ func (_self *ComponentNode) GetChildren() []Node {
 }
 
 func (_self *EndElementNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *CommentNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *TextNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *DynTextNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *AttributeNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *ArgumentAttributeNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *DynAttributeNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *EventAttributeNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
 	return []Node{}
 }
 
 func (_self *KeywordAttributeNode) GetChildren() []Node {
-	utils.Assert(false, "token has children property", 2)
+	return []Node{}
+}
+
+func (_self *ExpressionAttributeNode) GetChildren() []Node {
+	return []Node{}
+}
+
+func (_self *SpreadAttributeNode) GetChildren() []Node {
 	return []Node{}
 }
 
 // GetStartElementNode()
 
 func (_self *StartElementNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *ComponentNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
@@ -389,59 +487,56 @@ func (_self *EndElementNode) GetStartElementNode() Node {
 }
 
 func (_self *CommentNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *TextNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *DynTextNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *AttributeNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *ArgumentAttributeNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *DynAttributeNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *EventAttributeNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
 	return &StartElementNode{}
 }
 
 func (_self *KeywordAttributeNode) GetStartElementNode() Node {
-	utils.Assert(false, "token has startElementNode property", 2)
+	return &StartElementNode{}
+}
+
+func (_self *ExpressionAttributeNode) GetStartElementNode() Node {
+	return &StartElementNode{}
+}
+
+func (_self *SpreadAttributeNode) GetStartElementNode() Node {
 	return &StartElementNode{}
 }
 
 // GetData()
 
 func (_self *StartElementNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *ComponentNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *EndElementNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
@@ -454,59 +549,56 @@ func (_self *TextNode) GetData() string {
 }
 
 func (_self *DynTextNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *AttributeNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *ArgumentAttributeNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *DynAttributeNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *EventAttributeNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *KeywordAttributeNode) GetData() string {
-	utils.Assert(false, "token has data property", 2)
+	return ""
+}
+
+func (_self *ExpressionAttributeNode) GetData() string {
+	return ""
+}
+
+func (_self *SpreadAttributeNode) GetData() string {
 	return ""
 }
 
 // GetEffect()
 
 func (_self *StartElementNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
 func (_self *ComponentNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
 func (_self *EndElementNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
 func (_self *CommentNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
 func (_self *TextNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
@@ -515,12 +607,10 @@ func (_self *DynTextNode) GetEffect() string {
 }
 
 func (_self *AttributeNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
 func (_self *ArgumentAttributeNode) GetEffect() string {
-	utils.Assert(false, "token has effect property", 2)
 	return ""
 }
 
@@ -536,35 +626,37 @@ func (_self *KeywordAttributeNode) GetEffect() string {
 	return _self.effect
 }
 
+func (_self *ExpressionAttributeNode) GetEffect() string {
+	return _self.effect
+}
+
+func (_self *SpreadAttributeNode) GetEffect() string {
+	return _self.effect
+}
+
 // GetValue()
 
 func (_self *StartElementNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *ComponentNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *EndElementNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *CommentNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *TextNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *DynTextNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
@@ -573,7 +665,6 @@ func (_self *AttributeNode) GetValue() string {
 }
 
 func (_self *ArgumentAttributeNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
@@ -582,59 +673,56 @@ func (_self *DynAttributeNode) GetValue() string {
 }
 
 func (_self *EventAttributeNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
 	return ""
 }
 
 func (_self *KeywordAttributeNode) GetValue() string {
-	utils.Assert(false, "token has value property", 2)
+	return ""
+}
+
+func (_self *ExpressionAttributeNode) GetValue() string {
+	return ""
+}
+
+func (_self *SpreadAttributeNode) GetValue() string {
 	return ""
 }
 
 // GetEvent()
 
 func (_self *StartElementNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *ComponentNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *EndElementNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *CommentNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *TextNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *DynTextNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *AttributeNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *ArgumentAttributeNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
 func (_self *DynAttributeNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
@@ -643,10 +731,71 @@ func (_self *EventAttributeNode) GetEvent() string {
 }
 
 func (_self *KeywordAttributeNode) GetEvent() string {
-	utils.Assert(false, "token has event property", 2)
 	return ""
 }
 
+func (_self *ExpressionAttributeNode) GetEvent() string {
+	return ""
+}
+
+func (_self *SpreadAttributeNode) GetEvent() string {
+	return ""
+}
+
+// GetModifiers()
+
+func (_self *StartElementNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *ComponentNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *EndElementNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *CommentNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *TextNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *DynTextNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *AttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *ArgumentAttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *DynAttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *EventAttributeNode) GetModifiers() []string {
+	return _self.modifiers
+}
+
+func (_self *KeywordAttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *ExpressionAttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
+func (_self *SpreadAttributeNode) GetModifiers() []string {
+	return []string{}
+}
+
 // GetIsSelfClosing
 
 func (_self *StartElementNode) GetIsSelfClosing() bool {
@@ -658,94 +807,157 @@ func (_self *ComponentNode) GetIsSelfClosing() bool {
 }
 
 func (_self *EndElementNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *CommentNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *TextNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *DynTextNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *AttributeNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *ArgumentAttributeNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *DynAttributeNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *EventAttributeNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *KeywordAttributeNode) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
+func (_self *ExpressionAttributeNode) GetIsSelfClosing() bool {
+	return false
+}
+
+func (_self *SpreadAttributeNode) GetIsSelfClosing() bool {
+	return false
+}
+
+// GetPosition()
+
+func (_self *StartElementNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *ComponentNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *EndElementNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *CommentNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *TextNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *DynTextNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *AttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *ArgumentAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *DynAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *EventAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *KeywordAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *ExpressionAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
+func (_self *SpreadAttributeNode) GetPosition() tokens.Position {
+	return _self.position
+}
+
 // AppendToChildren()
 
-func (_self *StartElementNode) AppendToChildren(n Node) {
+func (_self *StartElementNode) AppendToChildren(n Node) error {
 	_self.children = append(_self.children, n)
+	return nil
 }
 
-func (_self *ComponentNode) AppendToChildren(n Node) {
+func (_self *ComponentNode) AppendToChildren(n Node) error {
 	_self.children = append(_self.children, n)
+	return nil
+}
+
+func (_self *EndElementNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *EndElementNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *CommentNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *CommentNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *TextNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *TextNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *DynTextNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *DynTextNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *AttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *AttributeNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *ArgumentAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *ArgumentAttributeNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *DynAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *DynAttributeNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *EventAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *EventAttributeNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *KeywordAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
-func (_self *KeywordAttributeNode) AppendToChildren(n Node) {
-	utils.Assert(false, "token has children property", 2)
+func (_self *ExpressionAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
+}
+
+func (_self *SpreadAttributeNode) AppendToChildren(n Node) error {
+	return noChildren(_self._type)
 }
 
 // Print()
@@ -871,10 +1083,11 @@ func (_self *EventAttributeNode) Print(depth *int) error {
 	for i := 0; i <= *depth; i++ {
 		indent = indent + " "
 	}
-	verbose.Printf(2, indent+"%s    %s    %s    {%s}\n",
+	verbose.Printf(2, indent+"%s    %s    %s    %v    {%s}\n",
 		_self._type,
 		_self.name,
 		_self.event,
+		_self.modifiers,
 		_self.effect)
 	return nil
 }
@@ -890,3 +1103,26 @@ func (_self *KeywordAttributeNode) Print(depth *int) error {
 		_self.effect)
 	return nil
 }
+
+func (_self *ExpressionAttributeNode) Print(depth *int) error {
+	var indent = ""
+	for i := 0; i <= *depth; i++ {
+		indent = indent + " "
+	}
+	verbose.Printf(2, indent+"%s    %s    {%s}\n",
+		_self._type,
+		_self.name,
+		_self.effect)
+	return nil
+}
+
+func (_self *SpreadAttributeNode) Print(depth *int) error {
+	var indent = ""
+	for i := 0; i <= *depth; i++ {
+		indent = indent + " "
+	}
+	verbose.Printf(2, indent+"%s    {...%s}\n",
+		_self._type,
+		_self.effect)
+	return nil
+}
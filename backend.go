@@ -0,0 +1,338 @@
+package stateparser
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Backend produces the Go code for each part of a view. The Parser walks the
+// AST and chains what the Backend returns, so a Backend decides which runtime
+// API the generated code targets.
+//
+// Text and Attr get text and attribute values as they are written in the
+// template, character references included.
+//
+// Element, Component and ChildBuilder start a new statement, Ref and List
+// wrap one, Child and Conditional attach a finished statement to its parent
+// and every other method returns a call appended to the current element.
+// OpenElement is appended once the attributes of an element are done and
+// CloseElement after its children.
+//
+// Static refers to a fully static subtree the Parser hoisted out of the
+// view, StaticDeclaration declares it once at package level.
+//
+// Class and Style get the static class or style attribute of an element
+// together with its class: and style: directives, so a Backend can write
+// them as one attribute. SlotProp names the props field a component gets
+// the builder of its children or of a slot in.
+//
+// Selected gets the signal a `<select>` binds with bind:value and the value
+// of one of its options, character references decoded, before the option's
+// start tag is closed.
+//
+// Conditional, List and DynText get the hydration ID of their region, empty
+// unless the Parser hydrates, so server-rendered markup and client code can
+// be matched up.
+//
+// ViewDeclaration declares the function name around the generated view,
+// and Imports lists the imports its code may need, such as `"fmt"` or
+// `. "github.com/goptos/system"`. A generated file keeps every dot import
+// and the others it refers to.
+//
+// CacheKey is part of the cache key of every view the Backend generates, a
+// Backend whose settings change the generated code returns them. The
+// built-in backends have none, Version covers their code.
+type Backend interface {
+	Element(namespace string, name string) string
+	OpenElement() string
+	CloseElement(namespace string, name string) string
+	Static(name string) string
+	StaticDeclaration(name string, html string) string
+	Component(name string, props []string, spread string) string
+	ChildBuilder() string
+	EndChildBuilder(statement string) string
+	Child(statement string) string
+	Conditional(id string, condition string, statement string) string
+	List(id string, statement string, collect string, key string, view string) string
+	Ref(statement string, holder string) string
+	Text(text string) string
+	DynText(id string, effect string) string
+	Attr(namespace string, name string, value string) string
+	DynAttrValue(effect string, name string) string
+	Class(value string, toggles []ClassToggle) string
+	Style(value string, properties []StyleProperty) string
+	SlotProp(field string) string
+	SpreadAttrs(effect string) string
+	Bind(property string, signal string, element string, attributes map[string]string) (string, error)
+	Selected(signal string, value string) string
+	Event(event string, modifiers []string, handler string) (string, error)
+	ViewDeclaration(name string, statement string) string
+	Imports() []string
+	CacheKey() string
+}
+
+// ClassToggle is a `class:name={condition}` directive.
+type ClassToggle struct {
+	Name      string
+	Condition string
+}
+
+// StyleProperty is a `style:name={value}` directive.
+type StyleProperty struct {
+	Name  string
+	Value string
+}
+
+// Runtime is the import path of the goptos runtime. Views use its types
+// unqualified and its functions as `system.Each`.
+const Runtime = "github.com/goptos/system"
+
+// GoptosBackend targets the goptos runtime, `(*Elem).New(nil, "div")`.
+type GoptosBackend struct{}
+
+// `<div>` => `(*Elem).New(nil, "div")`
+//
+// `<circle>` inside `<svg>` => `(*Elem).NewNS(nil, "http://www.w3.org/2000/svg", "circle")`
+func (GoptosBackend) Element(namespace string, name string) string {
+	if namespace != "" {
+		return fmt.Sprintf("(*Elem).NewNS(nil, %q, %q)", namespace, name)
+	}
+	return fmt.Sprintf("(*Elem).New(nil, %q)", name)
+}
+
+// The builder chain needs no separator between attributes and children.
+func (GoptosBackend) OpenElement() string {
+	return ""
+}
+
+func (GoptosBackend) CloseElement(namespace string, name string) string {
+	return ""
+}
+
+// `<p>Hi</p>` => `static0a1b2c3d.Clone()`
+func (GoptosBackend) Static(name string) string {
+	return fmt.Sprintf("%s.Clone()", name)
+}
+
+// `var static0a1b2c3d = (*Template).New(nil, "<p>Hi</p>")`
+func (GoptosBackend) StaticDeclaration(name string, html string) string {
+	return fmt.Sprintf("var %s = (*Template).New(nil, %q)", name, html)
+}
+
+// `<Button label="Save" />` => `Button.View(cx, ButtonProps{Label: "Save"})`
+//
+// `<Button {...props} label="Save" />` =>
+// `Button.View(cx, func() ButtonProps { p := props; p.Label = "Save"; return p }())`
+func (GoptosBackend) Component(name string, props []string, spread string) string {
+	return componentStatement(name, "View", "cx", props, spread)
+}
+
+// componentStatement calls method on the component with argument followed
+// by its props, if it has any. Props set next to a spread are assigned to a
+// copy of it, named so that it does not shadow anything the values use.
+func componentStatement(name string, method string, argument string, props []string, spread string) string {
+	if spread != "" && len(props) == 0 {
+		return fmt.Sprintf("%s.%s(%s, %s)", name, method, argument, spread)
+	}
+	if spread != "" {
+		var values = []string{}
+		for _, prop := range props {
+			var _, value, _ = strings.Cut(prop, ": ")
+			values = append(values, value)
+		}
+		var p = freeName("p", values...)
+		var assignments = ""
+		for _, prop := range props {
+			var field, value, _ = strings.Cut(prop, ": ")
+			assignments = assignments + fmt.Sprintf("%s.%s = %s; ", p, field, value)
+		}
+		return fmt.Sprintf("%s.%s(%s, func() %sProps { %s := %s; %sreturn %s }())",
+			name,
+			method,
+			argument,
+			name,
+			p,
+			spread,
+			assignments,
+			p)
+	}
+	if len(props) == 0 {
+		return fmt.Sprintf("%s.%s(%s)", name, method, argument)
+	}
+	return fmt.Sprintf("%s.%s(%s, %sProps{%s})",
+		name,
+		method,
+		argument,
+		name,
+		strings.Join(props, ", "))
+}
+
+// `<p>Hi</p>` inside a component => `func(e *Elem) *Elem { return e.Child(...) }`
+func (GoptosBackend) ChildBuilder() string {
+	return "func(e *Elem) *Elem { return e"
+}
+
+func (GoptosBackend) EndChildBuilder(statement string) string {
+	return statement + " }"
+}
+
+func (GoptosBackend) Child(statement string) string {
+	return fmt.Sprintf(".Child(%s)", statement)
+}
+
+// `<p if={fn}>` => `.DynChild(cx, fn, (*Elem).New(nil, "p"))`
+//
+// hydrating => `.HydrateDynChild(cx, "0.1", fn, (*Elem).New(nil, "p"))`
+func (GoptosBackend) Conditional(id string, condition string, statement string) string {
+	if id != "" {
+		return fmt.Sprintf(".HydrateDynChild(cx, %q, %s, %s)", id, condition, statement)
+	}
+	return fmt.Sprintf(".DynChild(cx, %s, %s)", condition, statement)
+}
+
+// `<ul each={cF} key={kF}><Li /></ul>` =>
+// `system.Each((*Elem).New(nil, "ul"), cx, cF, kF, Li.View)`
+//
+// hydrating => `system.HydrateEach((*Elem).New(nil, "ul"), cx, "0.2", cF, kF, Li.View)`
+func (GoptosBackend) List(id string, statement string, collect string, key string, view string) string {
+	if id != "" {
+		return fmt.Sprintf("system.HydrateEach(%s, cx, %q, %s, %s, %s.View)", statement, id, collect, key, view)
+	}
+	return fmt.Sprintf("system.Each(%s, cx, %s, %s, %s.View)", statement, collect, key, view)
+}
+
+// `<input ref={input} />` =>
+// `func() *Elem { e := (*Elem).New(nil, "input"); input.Set(e); return e }()`
+func (GoptosBackend) Ref(statement string, holder string) string {
+	return fmt.Sprintf("func() *Elem { e := %s; %s.Set(e); return e }()", statement, holder)
+}
+
+// `Hello &amp; bye` => `.Text("Hello & bye")`, character references are
+// decoded as they are in the HTML of hoisted subtrees.
+func (GoptosBackend) Text(text string) string {
+	return fmt.Sprintf(".Text(%s)", rawString(html.UnescapeString(text)))
+}
+
+// rawString is the raw string literal of s, so that multi-line text reads as
+// written, or its interpreted literal when a raw string cannot hold s.
+func rawString(s string) string {
+	if strings.ContainsAny(s, "`\r\x00\ufeff") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// `{count.Get()}` => `.DynText(cx, func() string { v := any(count.Get()); ... })`
+//
+// hydrating => `.HydrateDynText(cx, "0.0.1", func() string {...})`
+func (GoptosBackend) DynText(id string, effect string) string {
+	effect = valueEffect(effect)
+	if id != "" {
+		return fmt.Sprintf(".HydrateDynText(cx, %q, %s)", id, effect)
+	}
+	return fmt.Sprintf(".DynText(cx, %s)", effect)
+}
+
+// `id="sub-button"` => `.Attr("id", "sub-button")`
+//
+// `xlink:href="#icon"` => `.AttrNS("http://www.w3.org/1999/xlink", "xlink:href", "#icon")`
+//
+// Character references in the value are decoded like in Text.
+func (GoptosBackend) Attr(namespace string, name string, value string) string {
+	if namespace != "" {
+		return fmt.Sprintf(".AttrNS(%q, %q, %q)", namespace, name, html.UnescapeString(value))
+	}
+	return fmt.Sprintf(".Attr(%q, %q)", name, html.UnescapeString(value))
+}
+
+// valueEffect turns the value of a text expression, an attribute or a style
+// property into the `func() string` effect computing it. func() literals are
+// used as they are, a function value such as `fn` or `color.Get` is called
+// and any other value is formatted with fmt.Sprintf, each time the effect
+// runs.
+func valueEffect(effect string) string {
+	if strings.Split(effect, " ")[0] == "func()" {
+		return effect
+	}
+	return fmt.Sprintf("func() string { v := any(%s); if f, ok := v.(func() string); ok { return f() }; return fmt.Sprintf(\"%%v\", v) }",
+		effect)
+}
+
+// `href={fn}` => `.DynAttrValue(cx, func() string { v := any(fn); ... }, "href")`
+//
+// `href={ func() string {...} }` => `.DynAttrValue(cx, func() string {...}, "href")`
+func (GoptosBackend) DynAttrValue(effect string, name string) string {
+	return fmt.Sprintf(".DynAttrValue(cx, %s, %q)", valueEffect(effect), name)
+}
+
+// `class="item" class:dark={fn}` => `.Attr("class", "item").DynAttr(cx, fn, "class", "dark")`
+func (_self GoptosBackend) Class(value string, toggles []ClassToggle) string {
+	var statement = ""
+	if value != "" {
+		statement = _self.Attr("", "class", value)
+	}
+	for _, toggle := range toggles {
+		statement = statement + fmt.Sprintf(".DynAttr(cx, %s, \"class\", %q)", toggle.Condition, toggle.Name)
+	}
+	return statement
+}
+
+// `style:color={fn}` => `.DynStyle(cx, func() string { v := any(fn); ... }, "color")`,
+// the value follows the same rule as DynAttrValue.
+func (_self GoptosBackend) Style(value string, properties []StyleProperty) string {
+	var statement = ""
+	if value != "" {
+		statement = _self.Attr("", "style", value)
+	}
+	for _, property := range properties {
+		statement = statement + fmt.Sprintf(".DynStyle(cx, %s, %q)", valueEffect(property.Value), property.Name)
+	}
+	return statement
+}
+
+// `<slot:header>` => `Header: func(e *Elem) *Elem {...}`
+func (GoptosBackend) SlotProp(field string) string {
+	return field
+}
+
+// `{...attrs}` => `.SpreadAttrs(attrs)`
+func (GoptosBackend) SpreadAttrs(effect string) string {
+	return fmt.Sprintf(".SpreadAttrs(%s)", effect)
+}
+
+// `bind:value={name}` on `<input>` =>
+// `.DynProp(cx, func() any { return name.Get() }, "value").On("input", func(e Event) { name.Set(...) })`
+func (GoptosBackend) Bind(property string, signal string, element string, attributes map[string]string) (string, error) {
+	return bindStatement(property, signal, element, attributes)
+}
+
+// The value property the bound `<select>` sets selects its option.
+func (GoptosBackend) Selected(signal string, value string) string {
+	return ""
+}
+
+// `on:click={ func(Event) {} }` => `.On("click", func(Event))`
+//
+// `on:keydown|enter|preventDefault={fn}` =>
+// `.On("keydown", func(e Event) { if k := e.Key(); k != "Enter" { return }; e.PreventDefault(); (fn)(e) })`
+//
+// `on:click|once={fn}` => `.OnWithOptions("click", fn, EventOptions{Once: true})`
+func (GoptosBackend) Event(event string, modifiers []string, handler string) (string, error) {
+	return eventStatement(event, modifiers, handler)
+}
+
+// `func counterView(cx *Context) *Elem { return ... }`
+func (GoptosBackend) ViewDeclaration(name string, statement string) string {
+	return fmt.Sprintf("func %s(cx *Context) *Elem {\n\treturn %s\n}", name, statement)
+}
+
+func (GoptosBackend) Imports() []string {
+	return []string{`"fmt"`, fmt.Sprintf(". %q", Runtime), fmt.Sprintf("%q", Runtime)}
+}
+
+func (GoptosBackend) CacheKey() string {
+	return ""
+}
@@ -0,0 +1,110 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDynAttrValue checks that attribute values and style properties follow
+// one rule, `href={fn}` calls fn rather than formatting the function.
+func TestDynAttrValue(t *testing.T) {
+	var called = func(value string) string {
+		return "func() string { v := any(" + value + "); if f, ok := v.(func() string); ok { return f() }; return fmt.Sprintf(\"%v\", v) }"
+	}
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<a href={fn}></a>`,
+			`(*Elem).New(nil, "a").DynAttrValue(cx, ` + called("fn") + `, "href")`},
+		{`<a href={ url.Get }></a>`,
+			`(*Elem).New(nil, "a").DynAttrValue(cx, ` + called("url.Get") + `, "href")`},
+		{`<a tabindex={ i.Get() }></a>`,
+			`(*Elem).New(nil, "a").DynAttrValue(cx, ` + called("i.Get()") + `, "tabindex")`},
+		{`<a href={ func() string { return "/" + id } }></a>`,
+			`(*Elem).New(nil, "a").DynAttrValue(cx, func() string { return "/" + id }, "href")`},
+		{`<p style:color={fn}></p>`,
+			`(*Elem).New(nil, "p").DynStyle(cx, ` + called("fn") + `, "color")`},
+		{`<p style:color={ color.Get() }></p>`,
+			`(*Elem).New(nil, "p").DynStyle(cx, ` + called("color.Get()") + `, "color")`},
+		{`<p style:color={ func() string { return "red" } }></p>`,
+			`(*Elem).New(nil, "p").DynStyle(cx, func() string { return "red" }, "color")`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+// TestDynTextFunc checks that both backends render a function value in text
+// by calling it, as they do for attribute values.
+func TestDynTextFunc(t *testing.T) {
+	var called = `func() string { v := any(greet); if f, ok := v.(func() string); ok { return f() }; return fmt.Sprintf("%v", v) }`
+	for _, backend := range []Backend{GoptosBackend{}, HTMLBackend{}} {
+		var got = compileView(t, `<p>Hi { greet }</p>`, Options{Backend: backend})
+		if !strings.Contains(got, called) {
+			t.Errorf("%T: got %s, want greet called by %s", backend, got, called)
+		}
+	}
+}
+
+// traceBackend is a test double that records the Backend calls the Parser
+// makes for the methods it overrides.
+type traceBackend struct {
+	GoptosBackend
+}
+
+func (traceBackend) Element(namespace string, name string) string {
+	return "el(" + name + ")"
+}
+
+func (traceBackend) Child(statement string) string {
+	return " child(" + statement + ")"
+}
+
+func (traceBackend) Conditional(id string, condition string, statement string) string {
+	return " if(" + condition + ", " + statement + ")"
+}
+
+func (traceBackend) List(id string, statement string, collect string, key string, view string) string {
+	return "each(" + statement + ", " + collect + ", " + key + ", " + view + ")"
+}
+
+func (traceBackend) Text(text string) string {
+	return " text(" + text + ")"
+}
+
+func (traceBackend) DynText(id string, effect string) string {
+	return " dyntext(" + effect + ")"
+}
+
+func (traceBackend) Attr(namespace string, name string, value string) string {
+	return " attr(" + name + "=" + value + ")"
+}
+
+func (traceBackend) Event(event string, modifiers []string, handler string) (string, error) {
+	return " on(" + event + ", " + handler + ")", nil
+}
+
+// TestBackend checks that the Parser builds the view only from what its
+// Backend returns.
+func TestBackend(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<p id="a">Hi { name }</p>`, `el(p) attr(id=a) text(Hi) dyntext(name)`},
+		{`<div><button on:click={ save }>Save</button></div>`,
+			`el(div) child(el(button) on(click, save) text(Save))`},
+		{`<div><p if={ show }></p></div>`, `el(div) if(show, el(p))`},
+		{`<ul each={ items } key={ k }><Li /></ul>`, `each(el(ul), items, k, Li)`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{Backend: traceBackend{}})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
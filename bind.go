@@ -0,0 +1,69 @@
+package stateparser
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// bindStatement expands a two-way `bind:property={signal}` directive on an
+// element into a property effect plus the On handler that writes back to the
+// signal. attributes holds the static attributes of the element as they are
+// written, which decide the event and the conversion applied to the DOM value.
+//
+// Number inputs and selects parse their value into the signal's own type,
+// invalid input leaves the signal unchanged and a string signal takes the
+// value of a select whole. A checkbox `bind:group` adds or removes its
+// value from a slice signal, keeping the order of the other values.
+func bindStatement(property string, signal string, element string, attributes map[string]string) (string, error) {
+	var inputType = strings.ToLower(attributes["type"])
+	var value = html.UnescapeString(attributes["value"])
+	switch property {
+	case "value":
+		switch {
+		case element == "select":
+			var v, p, ok, err = freeName("v", signal), freeName("p", signal), freeName("ok", signal), freeName("err", signal)
+			return fmt.Sprintf(".DynProp(cx, func() any { return %s.Get() }, \"value\")"+
+				".On(\"change\", func(e Event) { %s := %s.Get(); "+
+				"if %s, %s := any(&%s).(*string); %s { *%s = e.Target().Get(\"value\").String() } "+
+				"else if _, %s := fmt.Sscan(e.Target().Get(\"value\").String(), &%s); %s != nil { return }; %s.Set(%s) })",
+				signal, v, signal,
+				p, ok, v, ok, p,
+				err, v, err, signal, v), nil
+		case element == "input" && (inputType == "number" || inputType == "range"):
+			var v, err = freeName("v", signal), freeName("err", signal)
+			return fmt.Sprintf(".DynProp(cx, func() any { return %s.Get() }, \"value\")"+
+				".On(\"input\", func(e Event) { %s := %s.Get(); "+
+				"if _, %s := fmt.Sscan(e.Target().Get(\"value\").String(), &%s); %s == nil { %s.Set(%s) } })",
+				signal, v, signal, err, v, err, signal, v), nil
+		case element == "input" || element == "textarea":
+			return fmt.Sprintf(".DynProp(cx, func() any { return %s.Get() }, \"value\")"+
+				".On(\"input\", func(e Event) { %s.Set(e.Target().Get(\"value\").String()) })",
+				signal, signal), nil
+		}
+	case "checked":
+		if element == "input" && inputType == "checkbox" {
+			return fmt.Sprintf(".DynProp(cx, func() any { return %s.Get() }, \"checked\")"+
+				".On(\"change\", func(e Event) { %s.Set(e.Target().Get(\"checked\").Bool()) })",
+				signal, signal), nil
+		}
+	case "group":
+		if element == "input" && inputType == "radio" {
+			return fmt.Sprintf(".DynProp(cx, func() any { return %s.Get() == %q }, \"checked\")"+
+				".On(\"change\", func(e Event) { %s.Set(e.Target().Get(\"value\").String()) })",
+				signal, value, signal), nil
+		}
+		if element == "input" && inputType == "checkbox" {
+			var values, v = freeName("values", signal), freeName("v", signal)
+			return fmt.Sprintf(".DynProp(cx, func() any { for _, %s := range %s.Get() { if %s == %q { return true } }; return false }, \"checked\")"+
+				".On(\"change\", func(e Event) { %s := %s.Get()[:0:0]; "+
+				"for _, %s := range %s.Get() { if %s != %q { %s = append(%s, %s) } }; "+
+				"if e.Target().Get(\"checked\").Bool() { %s = append(%s, %q) }; %s.Set(%s) })",
+				v, signal, v, value,
+				values, signal,
+				v, signal, v, value, values, values, v,
+				values, values, value, signal, values), nil
+		}
+	}
+	return "", fmt.Errorf("bind:%s is not supported on <%s type=%q>", property, element, inputType)
+}
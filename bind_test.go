@@ -0,0 +1,79 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   []string
+	}{
+		{`<input bind:value={ name } />`, []string{
+			`.DynProp(cx, func() any { return name.Get() }, "value")`,
+			`.On("input", func(e Event) { name.Set(e.Target().Get("value").String()) })`}},
+		{`<select bind:value={ choice }></select>`, []string{
+			`.On("change", func(e Event) { v := choice.Get(); if p, ok := any(&v).(*string); ok { *p = e.Target().Get("value").String() } ` +
+				`else if _, err := fmt.Sscan(e.Target().Get("value").String(), &v); err != nil { return }; choice.Set(v) })`}},
+		{`<input type="range" bind:value={ v } />`, []string{
+			`.On("input", func(e Event) { v1 := v.Get(); if _, err := fmt.Sscan(e.Target().Get("value").String(), &v1); err == nil { v.Set(v1) } })`}},
+		{`<input type="checkbox" bind:checked={ done } />`, []string{
+			`.DynProp(cx, func() any { return done.Get() }, "checked")`,
+			`.On("change", func(e Event) { done.Set(e.Target().Get("checked").Bool()) })`}},
+		{`<input type="radio" value="a" bind:group={ pick } />`, []string{
+			`.DynProp(cx, func() any { return pick.Get() == "a" }, "checked")`}},
+		{`<input type="checkbox" value="a" bind:group={ values } />`, []string{
+			`.DynProp(cx, func() any { for _, v := range values.Get() { if v == "a" { return true } }; return false }, "checked")`,
+			`values1 := values.Get()[:0:0]; for _, v := range values.Get() { if v != "a" { values1 = append(values1, v) } }; ` +
+				`if e.Target().Get("checked").Bool() { values1 = append(values1, "a") }; values.Set(values1)`}},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, want)
+			}
+		}
+	}
+}
+
+func TestBindHTML(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<input bind:value={ name } />`, `io.WriteString(w, " value=\""+`},
+		{`<input type="checkbox" bind:checked={ done } />`, `if done.Get() { io.WriteString(w, " checked") }`},
+		{`<input type="radio" value="a" bind:group={ pick } />`, `if pick.Get() == "a" { io.WriteString(w, " checked") }`},
+		{`<input type="checkbox" value="a" bind:group={ values } />`,
+			`for _, v := range values.Get() { if v == "a" { io.WriteString(w, " checked"); break } }`},
+		{`<input type="checkbox" value="a" bind:group={ v } />`,
+			`for _, v1 := range v.Get() { if v1 == "a" { io.WriteString(w, " checked"); break } }`},
+		{`<select bind:value={ n }><option value="1">One</option><option>Two</option></select>`,
+			`io.WriteString(w, "<option"); io.WriteString(w, " value=\"1\""); if fmt.Sprint(n.Get()) == "1" { io.WriteString(w, " selected") }; io.WriteString(w, ">"); ` +
+				`io.WriteString(w, "One"); io.WriteString(w, "</option>"); io.WriteString(w, "<option"); io.WriteString(w, ">")`},
+		{`<select bind:value={ n }><optgroup><option value="&amp;">&amp;</option></optgroup></select>`,
+			`if fmt.Sprint(n.Get()) == "&" { io.WriteString(w, " selected") }`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{Backend: HTMLBackend{}})
+		if !strings.Contains(got, test.want) {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, source := range []string{
+		`<div bind:value={ v }></div>`,
+		`<input type="text" bind:checked={ v } />`,
+		`<input type="text" bind:group={ v } />`,
+		`<input bind:files={ v } />`,
+	} {
+		var err = compileError(t, source, Options{})
+		if !strings.Contains(err.Error(), "is not supported on") {
+			t.Errorf("%s: got %v", source, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package stateparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+// Version is part of every cache key, it changes whenever the code generated
+// for the same template and options changes.
+const Version = "0.2.3"
+
+// Cache stores compiled views on disk, keyed by the template and everything
+// that changes the code generated for it: the program compiling the views,
+// the Backend, the options and the registered directives.
+type Cache struct {
+	Dir string
+}
+
+type cacheEntry struct {
+	Output   Output           `json:"output"`
+	Error    string           `json:"error,omitempty"`
+	Position *tokens.Position `json:"position,omitempty"`
+}
+
+// NewCache returns a Cache storing its entries below dir.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+var generator struct {
+	once sync.Once
+	hash string
+}
+
+// generatorHash is the SHA-256 of the running executable, so entries written
+// by any other build, a `go run` of changed code included, are never used.
+// It is empty when the executable cannot be read, and Compile then does not
+// use the cache.
+func generatorHash() string {
+	generator.once.Do(func() {
+		var path, err = os.Executable()
+		if err != nil {
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		var hash = sha256.New()
+		_, err = io.Copy(hash, file)
+		if err != nil {
+			return
+		}
+		generator.hash = hex.EncodeToString(hash.Sum(nil))
+	})
+	return generator.hash
+}
+
+// Key returns the cache key of compiling source with opts. The Backend is
+// keyed by its type and its CacheKey. Directive handlers cannot be compared,
+// but they are part of the executable.
+func (_self *Cache) Key(source string, opts Options) string {
+	var hash = sha256.New()
+	var backend Backend = GoptosBackend{}
+	if opts.Backend != nil {
+		backend = opts.Backend
+	}
+	fmt.Fprintf(hash, "stateparser %s\n%s\n%T %q\n%t %t %q\n",
+		Version,
+		generatorHash(),
+		backend,
+		backend.CacheKey(),
+		opts.Hydrate,
+		opts.Hoist,
+		opts.StaticPrefix)
+	for _, directive := range opts.Directives {
+		fmt.Fprintf(hash, "directive %q %q\n", directive.Name, directive.Version)
+	}
+	io.WriteString(hash, source)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (_self *Cache) path(key string) string {
+	return filepath.Join(_self.Dir, key[:2], key+".json")
+}
+
+// Get returns the output and the diagnostic stored under key, ok is false
+// when there is no entry. A diagnostic put with a position is a
+// *tokens.Error again.
+func (_self *Cache) Get(key string) (output Output, diagnostic error, ok bool) {
+	var data, err = os.ReadFile(_self.path(key))
+	if err != nil {
+		return Output{}, nil, false
+	}
+	var entry cacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return Output{}, nil, false
+	}
+	if entry.Error == "" {
+		return entry.Output, nil, true
+	}
+	err = errors.New(entry.Error)
+	if entry.Position != nil {
+		err = &tokens.Error{Position: *entry.Position, Err: err}
+	}
+	return Output{}, err, true
+}
+
+// Put stores output and the diagnostic err under key. The entry is written
+// to a temporary file and renamed, so concurrent compilers never read half
+// an entry.
+func (_self *Cache) Put(key string, output Output, err error) error {
+	var entry = cacheEntry{Output: output}
+	if err != nil {
+		entry.Error = err.Error()
+		var positioned *tokens.Error
+		if errors.As(err, &positioned) {
+			entry.Error = positioned.Err.Error()
+			entry.Position = &positioned.Position
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	var path = _self.path(key)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
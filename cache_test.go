@@ -0,0 +1,133 @@
+package stateparser
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/goptos/stateparser/ast/nodes"
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+func TestCacheKey(t *testing.T) {
+	var cache = NewCache(t.TempDir())
+	var handler = func(node nodes.Node, value string, builder *Builder) error { return nil }
+	var source = `<p></p>`
+	var key = cache.Key(source, Options{})
+	if key != cache.Key(source, Options{}) {
+		t.Error("the same view has two keys")
+	}
+	if key != cache.Key(source, Options{Backend: GoptosBackend{}}) {
+		t.Error("the default Backend changes the key")
+	}
+	var changed = map[string]string{}
+	for name, opts := range map[string]Options{
+		"backend":       {Backend: HTMLBackend{}},
+		"hydrate":       {Hydrate: true},
+		"hoist":         {Hoist: true},
+		"static prefix": {StaticPrefix: "aView"},
+		"directive":     {Directives: []Directive{{Name: "x", Handler: handler, Version: "1"}}},
+		"version":       {Directives: []Directive{{Name: "x", Handler: handler, Version: "2"}}},
+	} {
+		changed[name] = cache.Key(source, opts)
+	}
+	changed["source"] = cache.Key(`<b></b>`, Options{})
+	var seen = map[string]string{key: "default"}
+	for name, other := range changed {
+		if previous, ok := seen[other]; ok {
+			t.Errorf("%s has the key of %s", name, previous)
+		}
+		seen[other] = name
+	}
+	if generatorHash() == "" {
+		t.Error("the test binary has no hash")
+	}
+}
+
+func TestCacheEntries(t *testing.T) {
+	var cache = NewCache(t.TempDir())
+	if _, _, ok := cache.Get("00missing"); ok {
+		t.Error("got an entry that was never put")
+	}
+	var output = Output{Result: "result", Statics: []string{"static"}}
+	err := cache.Put("00output", output, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, diagnostic, ok := cache.Get("00output")
+	if !ok || diagnostic != nil || got.Result != "result" || len(got.Statics) != 1 {
+		t.Errorf("got %+v %v %t, want %+v", got, diagnostic, ok, output)
+	}
+	var position = tokens.Position{StartLine: 2, StartColumn: 3, EndLine: 2, EndColumn: 5}
+	err = cache.Put("00error", Output{}, &tokens.Error{Position: position, Err: errors.New("eof-in-tag")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, diagnostic, ok = cache.Get("00error")
+	var positioned *tokens.Error
+	if !ok || !errors.As(diagnostic, &positioned) || positioned.Position != position || positioned.Err.Error() != "eof-in-tag" {
+		t.Errorf("got %v %t, want eof-in-tag at %v", diagnostic, ok, position)
+	}
+	os.WriteFile(cache.path("00corrupt"), []byte("{"), 0o644)
+	if _, _, ok := cache.Get("00corrupt"); ok {
+		t.Error("got a corrupt entry")
+	}
+}
+
+// TestCompileCache checks that Compile returns what the cache holds for an
+// unchanged view, diagnostics included, and compiles a changed one.
+func TestCompileCache(t *testing.T) {
+	var opts = Options{Cache: NewCache(t.TempDir())}
+	var source = `<p></p>`
+	_, err := Compile(source, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key = opts.Cache.Key(source, opts)
+	if _, _, ok := opts.Cache.Get(key); !ok {
+		t.Fatal("Compile did not store its output")
+	}
+	opts.Cache.Put(key, Output{Result: "cached"}, nil)
+	output, err := Compile(source, opts)
+	if err != nil || output.Result != "cached" {
+		t.Errorf("got %s %v, want the cached output", output.Result, err)
+	}
+	_, err = Compile(`<p on:={ f }></p>`, opts)
+	_, cached := Compile(`<p on:={ f }></p>`, opts)
+	if err == nil || cached == nil || err.Error() != cached.Error() {
+		t.Errorf("got %v, then %v from the cache", err, cached)
+	}
+	var positioned, cachedPositioned *tokens.Error
+	if !errors.As(err, &positioned) || !errors.As(cached, &cachedPositioned) ||
+		positioned.Position != cachedPositioned.Position {
+		t.Errorf("got %#v, then %#v from the cache, want a *tokens.Error at the same position", err, cached)
+	}
+}
+
+// keyedBackend is a Backend with a setting that changes its code.
+type keyedBackend struct {
+	GoptosBackend
+	version string
+	scratch *int
+}
+
+func (_self keyedBackend) CacheKey() string {
+	return _self.version
+}
+
+// TestCacheKeyBackend checks that a Backend is keyed by its type and its
+// CacheKey, not by the values of its fields.
+func TestCacheKeyBackend(t *testing.T) {
+	var cache = NewCache(t.TempDir())
+	var source = `<p></p>`
+	var key = cache.Key(source, Options{Backend: keyedBackend{version: "1", scratch: new(int)}})
+	if key != cache.Key(source, Options{Backend: keyedBackend{version: "1", scratch: new(int)}}) {
+		t.Error("fields outside CacheKey change the key")
+	}
+	if key == cache.Key(source, Options{Backend: keyedBackend{version: "2"}}) {
+		t.Error("CacheKey does not change the key")
+	}
+	if cache.Key(source, Options{Backend: traceBackend{}}) == cache.Key(source, Options{}) {
+		t.Error("a Backend embedding GoptosBackend has its key")
+	}
+}
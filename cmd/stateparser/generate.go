@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goptos/stateparser"
+)
+
+// templateSuffix marks the view templates the commands work on,
+// `counter.view.html` generates `counter.view.go`.
+const templateSuffix = ".view.html"
+
+// outputPath is the Go file generated from template.
+func outputPath(template string) string {
+	return strings.TrimSuffix(template, templateSuffix) + ".view.go"
+}
+
+// viewFunc names the function generated from template, `todo-item.view.html`
+// declares `todoItemView`.
+func viewFunc(template string) string {
+	var name = strings.TrimSuffix(filepath.Base(template), templateSuffix)
+	var result = []rune{}
+	var upper = false
+	for _, r := range name {
+		if r == '-' || r == '_' || r == '.' {
+			upper = len(result) > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		result = append(result, r)
+	}
+	return string(result) + "View"
+}
+
+// packageName returns the package of the Go files next to template, or the
+// name of its directory made into an identifier.
+func packageName(template string) string {
+	var files, _ = filepath.Glob(filepath.Join(filepath.Dir(template), "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		var source, err = os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(source), "\n") {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(line), "package "); ok {
+				return strings.TrimSpace(name)
+			}
+		}
+	}
+	var abs, _ = filepath.Abs(filepath.Dir(template))
+	var name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, filepath.Base(abs))
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	return name
+}
+
+// generate turns the view of template compiled with backend into the Go
+// file declaring it, next to the subtrees it hoisted.
+func generate(template string, output stateparser.Output, backend stateparser.Backend) ([]byte, error) {
+	var declarations bytes.Buffer
+	for _, static := range output.Statics {
+		fmt.Fprintf(&declarations, "%s\n\n", static)
+	}
+	fmt.Fprintf(&declarations, "%s\n", backend.ViewDeclaration(viewFunc(template), strings.TrimSuffix(output.Result, "\r")))
+	imports, err := usedImports(declarations.Bytes(), backend.Imports())
+	if err != nil {
+		return nil, err
+	}
+	var code bytes.Buffer
+	fmt.Fprintf(&code, "// Code generated by stateparser from %s. DO NOT EDIT.\n\n", filepath.Base(template))
+	fmt.Fprintf(&code, "package %s\n\n", packageName(template))
+	if len(imports) > 0 {
+		fmt.Fprintf(&code, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	}
+	code.Write(declarations.Bytes())
+	formatted, err := format.Source(code.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w", err)
+	}
+	return formatted, nil
+}
+
+// usedImports returns the imports of the Backend that declarations need,
+// every dot import and the packages named by identifiers the code does not
+// declare, `fmt.Sprintf` needs `"fmt"` and a `fmt` in a string does not.
+func usedImports(declarations []byte, imports []string) ([]string, error) {
+	var file, err = parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n\n"), declarations...), 0)
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w", err)
+	}
+	var packages = make(map[string]interface{})
+	for _, ident := range file.Unresolved {
+		packages[ident.Name] = nil
+	}
+	var used = []string{}
+	for _, spec := range imports {
+		var fields = strings.Fields(spec)
+		path, err := strconv.Unquote(fields[len(fields)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid import %s: %w", spec, err)
+		}
+		var name = path[strings.LastIndex(path, "/")+1:]
+		if len(fields) > 1 {
+			name = fields[0]
+		}
+		if _, ok := packages[name]; ok || name == "." {
+			used = append(used, spec)
+		}
+	}
+	return used, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goptos/stateparser"
+)
+
+func TestViewFunc(t *testing.T) {
+	var tests = map[string]string{
+		"counter.view.html":               "counterView",
+		"views/todo-item.view.html":       "todoItemView",
+		"user_card.view.html":             "userCardView",
+		filepath.Join("a", "b.view.html"): "bView",
+	}
+	for template, want := range tests {
+		if got := viewFunc(template); got != want {
+			t.Errorf("%s: got %s, want %s", template, got, want)
+		}
+	}
+	if got := outputPath("views/counter.view.html"); got != "views/counter.view.go" {
+		t.Errorf("got %s, want views/counter.view.go", got)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	var dir = filepath.Join(t.TempDir(), "my-views")
+	err := os.Mkdir(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var template = filepath.Join(dir, "a.view.html")
+	if got := packageName(template); got != "my_views" {
+		t.Errorf("got %s, want the directory name my_views", got)
+	}
+	if got := packageName(filepath.Join("2024", "a.view.html")); got != "_2024" {
+		t.Errorf("got %s, want _2024", got)
+	}
+	err = os.WriteFile(filepath.Join(dir, "app.go"), []byte("// Package app.\npackage app\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := packageName(template); got != "app" {
+		t.Errorf("got %s, want the package of app.go", got)
+	}
+}
+
+// TestGenerate type checks the files generate writes, with the imports the
+// views need and no others.
+func TestGenerate(t *testing.T) {
+	var imports = newStubImporter(t)
+	var tests = []struct {
+		source string
+		opts   stateparser.Options
+	}{
+		{`<div><p>Intro</p><b>{ count }</b></div>`, stateparser.Options{Hoist: true}},
+		{`<ul each={ items } key={ itemKey }><Item /></ul>`, stateparser.Options{}},
+		{`<p>fmt.Println</p>`, stateparser.Options{}},
+		{`<div><p>Intro</p><b>{ count }</b></div>`, stateparser.Options{Backend: stateparser.HTMLBackend{}, Hoist: true}},
+		{`<p class:on={ on }>fmt.Println</p>`, stateparser.Options{Backend: stateparser.HTMLBackend{}}},
+	}
+	for _, test := range tests {
+		var dir = t.TempDir()
+		var template = filepath.Join(dir, "list.view.html")
+		var backend stateparser.Backend = stateparser.GoptosBackend{}
+		if test.opts.Backend != nil {
+			backend = test.opts.Backend
+		}
+		test.opts.StaticPrefix = viewFunc(template)
+		output, err := stateparser.Compile(test.source, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, "list.go"), []byte(listDeclarations), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generate(template, output, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(outputPath(template), code, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = checkPackage(imports.fset, "list", dir, imports)
+		if err != nil {
+			t.Errorf("%s:\n%s\ndoes not type check:\n%s", test.source, code, err)
+		}
+		for _, static := range output.Statics {
+			if !strings.Contains(string(code), static) {
+				t.Errorf("%s does not declare %s", code, static)
+			}
+		}
+	}
+}
+
+// listDeclarations are the identifiers the views of TestGenerate use.
+const listDeclarations = `package list
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+var count int
+var on func() bool
+var items func() []int
+
+func itemKey(item int) int { return item }
+
+type itemView struct{}
+
+func (itemView) View(cx *system.Context, item int) *system.Elem { return nil }
+func (itemView) Render(w io.Writer, item int)                    {}
+
+var Item itemView
+`
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+type foundType struct {
+	file      string
+	nameRange lspRange
+	source    string
+}
+
+// findType looks for the declaration of the Go type name, first in the Go
+// files of dirs[0] and then in every package below the other dirs.
+func findType(dirs []string, name string) (foundType, bool) {
+	for i, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if i == 0 {
+			var found, ok = findTypeInDir(dir, name)
+			if ok {
+				return found, true
+			}
+			continue
+		}
+		var result foundType
+		var ok = false
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.IsDir() {
+				return nil
+			}
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor" || entry.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			result, ok = findTypeInDir(path, name)
+			if ok {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if ok {
+			return result, true
+		}
+	}
+	return foundType{}, false
+}
+
+func findTypeInDir(dir string, name string) (foundType, bool) {
+	var files, _ = filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		var source, err = os.ReadFile(file)
+		if err != nil || !strings.Contains(string(source), name) {
+			continue
+		}
+		var fset = token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, file, source, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range parsed.Decls {
+			var genDecl, ok = decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				var typeSpec = spec.(*ast.TypeSpec)
+				if typeSpec.Name.Name != name {
+					continue
+				}
+				var start = fset.Position(typeSpec.Name.Pos())
+				var end = fset.Position(typeSpec.Name.End())
+				var from, to = fset.Position(genDecl.Pos()).Offset, fset.Position(genDecl.End()).Offset
+				if genDecl.Lparen.IsValid() {
+					from, to = fset.Position(typeSpec.Pos()).Offset, fset.Position(typeSpec.End()).Offset
+				}
+				var text = string(source[from:to])
+				if genDecl.Lparen.IsValid() {
+					text = "type " + text
+				}
+				var doc = typeSpec.Doc
+				if doc == nil {
+					doc = genDecl.Doc
+				}
+				if doc != nil {
+					for i := len(doc.List) - 1; i >= 0; i-- {
+						text = doc.List[i].Text + "\n" + text
+					}
+				}
+				return foundType{
+					file: file,
+					nameRange: toRange(tokens.Position{
+						StartLine:        start.Line,
+						StartColumn:      start.Column,
+						StartColumnUTF16: utf16Column(source, start.Offset),
+						StartOffset:      start.Offset,
+						EndLine:          end.Line,
+						EndColumn:        end.Column - 1,
+						EndColumnUTF16:   utf16Column(source, end.Offset) - 1,
+						EndOffset:        end.Offset,
+					}),
+					source: text,
+				}, true
+			}
+		}
+	}
+	return foundType{}, false
+}
+
+// utf16Column returns the 1-based column of the byte at offset in source,
+// counted in UTF-16 code units like the protocol counts characters, where
+// go/token counts bytes.
+func utf16Column(source []byte, offset int) int {
+	var lineStart = bytes.LastIndexByte(source[:offset], '\n') + 1
+	return len(utf16.Encode([]rune(string(source[lineStart:offset])))) + 1
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindType(t *testing.T) {
+	var dir = t.TempDir()
+	var source = "package ui\n\n// Card is a card.\ntype (𝒜 int; Card struct{})\n"
+	err := os.WriteFile(filepath.Join(dir, "card.go"), []byte(source), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, ok := findType([]string{dir}, "Card")
+	if !ok {
+		t.Fatal("Card not found")
+	}
+	var want = lspRange{Start: position{Line: 3, Character: 14}, End: position{Line: 3, Character: 18}}
+	if found.nameRange != want {
+		t.Errorf("got %+v, want %+v in UTF-16 code units", found.nameRange, want)
+	}
+	if found.source != "// Card is a card.\ntype Card struct{}" {
+		t.Errorf("got %q, want the declaration of Card", found.source)
+	}
+	if _, ok := findType([]string{"", dir}, "Missing"); ok {
+		t.Error("found a type that is not declared")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/base/0.9/specification/
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	methodNotFound = -32601
+	invalidParams  = -32602
+)
+
+type conn struct {
+	reader *textproto.Reader
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: w,
+	}
+}
+
+// read returns the next message, io.EOF once the client hangs up.
+func (_self *conn) read() (*message, error) {
+	header, err := _self.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	var body = make([]byte, length)
+	_, err = io.ReadFull(_self.reader.R, body)
+	if err != nil {
+		return nil, err
+	}
+	var msg = &message{}
+	err = json.Unmarshal(body, msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (_self *conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_self.mutex.Lock()
+	defer _self.mutex.Unlock()
+	_, err = fmt.Fprintf(_self.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// reply answers request id. A nil result is sent as `null`, as the protocol
+// requires a result on success.
+func (_self *conn) reply(id *json.RawMessage, result interface{}) error {
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return _self.write(&message{ID: id, Result: result})
+}
+
+func (_self *conn) replyError(id *json.RawMessage, code int, text string) error {
+	return _self.write(&message{ID: id, Error: &responseError{Code: code, Message: text}})
+}
+
+func (_self *conn) notify(method string, params interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return _self.write(&message{Method: method, Params: body})
+}
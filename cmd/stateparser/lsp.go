@@ -0,0 +1,497 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/goptos/stateparser"
+	"github.com/goptos/stateparser/lexer"
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    *lspRange     `json:"range,omitempty"`
+}
+
+type completionItem struct {
+	Label      string `json:"label"`
+	Kind       int    `json:"kind"`
+	Detail     string `json:"detail,omitempty"`
+	InsertText string `json:"insertText,omitempty"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+}
+
+const (
+	severityError       = 1
+	completionKeyword   = 14
+	completionEvent     = 23
+	completionNamespace = 9
+)
+
+// Hover text for the built-in directives and directive namespaces.
+var directiveDocs = map[string]string{
+	"if":    "`if={fn}` renders the element while `fn` returns true.",
+	"each":  "`each={collect}` renders the self-closing component child once for every item `collect` returns, it needs a `key`.",
+	"key":   "`key={fn}` identifies the items rendered by `each`.",
+	"ref":   "`ref={holder}` calls `holder.Set` with the element once it is created.",
+	"on":    "`on:event|modifiers={handler}` listens to a DOM event. Modifiers are key filters, `preventDefault`, `stopPropagation`, `once`, `capture` and `passive`.",
+	"bind":  "`bind:property={signal}` keeps `value`, `checked` or a radio or checkbox `group` in sync with a signal.",
+	"class": "`class:name={fn}` adds the class while `fn` returns true.",
+	"style": "`style:property={value}` sets a style property, a function value is called and any other value is formatted.",
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/Events
+var eventNames = []string{
+	"blur", "change", "click", "contextmenu", "dblclick", "focus",
+	"focusin", "focusout", "input", "keydown", "keyup", "mousedown",
+	"mouseenter", "mouseleave", "mousemove", "mouseout", "mouseover", "mouseup",
+	"pointerdown", "pointermove", "pointerup", "reset", "scroll", "submit",
+	"touchend", "touchmove", "touchstart", "wheel",
+}
+
+type server struct {
+	conn      *conn
+	root      string
+	documents map[string]string
+	shutdown  bool
+}
+
+// runLSP serves one client until it exits. GOPTOS_VERBOSE makes the lexer
+// and parser trace to stdout, which would corrupt the protocol served on it.
+func runLSP(r io.Reader, w io.Writer) error {
+	if w == os.Stdout && os.Getenv("GOPTOS_VERBOSE") != "" {
+		return fmt.Errorf("GOPTOS_VERBOSE traces to stdout, unset it to serve the protocol")
+	}
+	var s = &server{
+		conn:      newConn(r, w),
+		root:      "",
+		documents: make(map[string]string),
+		shutdown:  false,
+	}
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+		err = s.handle(msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (_self *server) handle(msg *message) error {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			RootURI string `json:"rootUri"`
+		}
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		_self.root = uriToPath(params.RootURI)
+		return _self.conn.reply(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1,
+				"hoverProvider":      true,
+				"definitionProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{" ", ":"},
+				},
+			},
+			"serverInfo": map[string]string{"name": "stateparser"},
+		})
+	case "shutdown":
+		_self.shutdown = true
+		return _self.conn.reply(msg.ID, nil)
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		_self.documents[params.TextDocument.URI] = params.TextDocument.Text
+		return _self.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil
+		}
+		_self.documents[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return _self.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params textDocumentPositionParams
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		delete(_self.documents, params.TextDocument.URI)
+		return _self.conn.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         params.TextDocument.URI,
+			"diagnostics": []diagnostic{},
+		})
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		result := _self.hover(params)
+		if result == nil {
+			return _self.conn.reply(msg.ID, nil)
+		}
+		return _self.conn.reply(msg.ID, result)
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		result := _self.definition(params)
+		if result == nil {
+			return _self.conn.reply(msg.ID, nil)
+		}
+		return _self.conn.reply(msg.ID, result)
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return _self.rejectParams(msg, err)
+		}
+		return _self.conn.reply(msg.ID, _self.completion(params))
+	}
+	if msg.ID != nil {
+		return _self.conn.replyError(msg.ID, methodNotFound, fmt.Sprintf("method %q not supported", msg.Method))
+	}
+	return nil
+}
+
+// rejectParams answers a request whose params do not decode with an
+// InvalidParams error. A notification gets no reply and is dropped.
+func (_self *server) rejectParams(msg *message, err error) error {
+	if msg.ID == nil {
+		return nil
+	}
+	return _self.conn.replyError(msg.ID, invalidParams, err.Error())
+}
+
+func (_self *server) publishDiagnostics(uri string) error {
+	var diagnostics = []diagnostic{}
+	err := parse(_self.documents[uri])
+	if err != nil {
+		diagnostics = append(diagnostics, errorDiagnostic(err))
+	}
+	return _self.conn.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// parse runs the whole pipeline, Lexer.Next, Ast.Create and ParseView, over
+// source.
+func parse(source string) error {
+	return stateparser.New().ParseView(source)
+}
+
+// tokenise returns the tokens lexed before any error, the directives of the
+// parser are keyword attributes.
+func tokenise(source string) []tokens.Token {
+	var l = lexer.New(source)
+	for _, name := range stateparser.New().DirectiveNames() {
+		l.KeywordAttributeNames[name] = nil
+	}
+	l.Tokenise()
+	return l.Tokens
+}
+
+func errorDiagnostic(err error) diagnostic {
+	var d = diagnostic{
+		Severity: severityError,
+		Source:   "stateparser",
+		Message:  err.Error(),
+	}
+	var positioned *tokens.Error
+	if errors.As(err, &positioned) {
+		d.Range = toRange(positioned.Position)
+		d.Message = positioned.Err.Error()
+	}
+	return d
+}
+
+// toRange converts a lexer position, 1-based with an inclusive end, to a
+// protocol range, 0-based with an exclusive end. Characters are counted in
+// UTF-16 code units.
+func toRange(p tokens.Position) lspRange {
+	var r = lspRange{
+		Start: position{Line: max(p.StartLine-1, 0), Character: max(p.StartColumnUTF16-1, 0)},
+		End:   position{Line: max(p.EndLine-1, 0), Character: max(p.EndColumnUTF16, 0)},
+	}
+	if r.End.Line < r.Start.Line || (r.End.Line == r.Start.Line && r.End.Character < r.Start.Character) {
+		r.End = r.Start
+	}
+	return r
+}
+
+func contains(p tokens.Position, at position) bool {
+	var line, column = at.Line + 1, at.Character + 1
+	if line < p.StartLine || line > p.EndLine {
+		return false
+	}
+	if line == p.StartLine && column < p.StartColumnUTF16 {
+		return false
+	}
+	if line == p.EndLine && column > p.EndColumnUTF16 {
+		return false
+	}
+	return true
+}
+
+// tagNamePosition is the position of the name of a StartTag or EndTag token.
+func tagNamePosition(token tokens.Token) tokens.Position {
+	var p = token.GetPosition()
+	var offset = 1
+	if token.GetType() == tokens.EndTag {
+		offset = 2
+	}
+	p.StartColumn += offset
+	p.StartColumnUTF16 += offset
+	p.StartOffset += offset
+	p.EndLine = p.StartLine
+	p.EndColumn = p.StartColumn - 1
+	p.EndColumnUTF16 = p.StartColumnUTF16 - 1
+	p.EndOffset = p.StartOffset
+	for _, r := range token.GetName() {
+		p.Extend(r)
+	}
+	return p
+}
+
+// at finds the tag or attribute name under the cursor.
+func at(source string, cursor position) (tokens.Token, *tokens.Attribute) {
+	for _, token := range tokenise(source) {
+		if token.GetType() != tokens.StartTag && token.GetType() != tokens.EndTag {
+			continue
+		}
+		if contains(tagNamePosition(token), cursor) {
+			return token, nil
+		}
+		if token.GetType() != tokens.StartTag {
+			continue
+		}
+		for _, attribute := range token.GetAttributes() {
+			if contains(attribute.NamePosition, cursor) {
+				return token, &attribute
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (_self *server) hover(params textDocumentPositionParams) *hover {
+	var token, attribute = at(_self.documents[params.TextDocument.URI], params.Position)
+	if token == nil {
+		return nil
+	}
+	if attribute != nil {
+		var doc, ok = "", false
+		if namespace, _, found := strings.Cut(attribute.Name, ":"); found {
+			doc, ok = directiveDocs[namespace]
+		} else if attribute.Type == tokens.KeywordAttribute {
+			doc, ok = directiveDocs[attribute.Name]
+			if !ok {
+				doc, ok = fmt.Sprintf("`%s` is a registered keyword attribute.", attribute.Name), true
+			}
+		}
+		if !ok {
+			return nil
+		}
+		var r = toRange(attribute.NamePosition)
+		return &hover{Contents: markupContent{Kind: "markdown", Value: doc}, Range: &r}
+	}
+	if !isComponent(token.GetName()) {
+		return nil
+	}
+	var doc = fmt.Sprintf("component `%s`", token.GetName())
+	var declarations = []string{}
+	for _, name := range []string{token.GetName(), token.GetName() + "Props"} {
+		var found, ok = findType(_self.searchDirs(params.TextDocument.URI), name)
+		if ok {
+			declarations = append(declarations, found.source)
+		}
+	}
+	if len(declarations) > 0 {
+		doc = "```go\n" + strings.Join(declarations, "\n\n") + "\n```"
+	}
+	var r = toRange(tagNamePosition(token))
+	return &hover{Contents: markupContent{Kind: "markdown", Value: doc}, Range: &r}
+}
+
+func (_self *server) definition(params textDocumentPositionParams) *location {
+	var token, attribute = at(_self.documents[params.TextDocument.URI], params.Position)
+	if token == nil || attribute != nil || !isComponent(token.GetName()) {
+		return nil
+	}
+	var found, ok = findType(_self.searchDirs(params.TextDocument.URI), token.GetName())
+	if !ok {
+		return nil
+	}
+	return &location{URI: pathToURI(found.file), Range: found.nameRange}
+}
+
+func (_self *server) completion(params textDocumentPositionParams) []completionItem {
+	var items = []completionItem{}
+	var word, ok = attributeWord(_self.documents[params.TextDocument.URI], params.Position)
+	if !ok {
+		return items
+	}
+	if strings.HasPrefix(word, "on:") {
+		for _, event := range eventNames {
+			items = append(items, completionItem{
+				Label:      "on:" + event,
+				Kind:       completionEvent,
+				Detail:     "DOM event",
+				InsertText: "on:" + event + "={}",
+			})
+		}
+		return items
+	}
+	var names = stateparser.New().DirectiveNames()
+	sort.Strings(names)
+	for _, name := range names {
+		items = append(items, completionItem{
+			Label:      name,
+			Kind:       completionKeyword,
+			Detail:     "keyword attribute",
+			InsertText: name + "={}",
+		})
+	}
+	for _, namespace := range []string{"on", "bind", "class", "style"} {
+		items = append(items, completionItem{
+			Label:  namespace + ":",
+			Kind:   completionNamespace,
+			Detail: "directive",
+		})
+	}
+	return items
+}
+
+// attributeWord returns the partial attribute name before the cursor when
+// the cursor is inside a start tag, after its name.
+func attributeWord(source string, cursor position) (string, bool) {
+	var lines = strings.Split(source, "\n")
+	if cursor.Line >= len(lines) {
+		return "", false
+	}
+	var before = strings.Join(lines[:cursor.Line], "\n")
+	var line = utf16.Encode([]rune(lines[cursor.Line]))
+	if cursor.Line > 0 {
+		before = before + "\n"
+	}
+	before = before + string(utf16.Decode(line[:min(cursor.Character, len(line))]))
+	var open = strings.LastIndex(before, "<")
+	if open < 0 || open < strings.LastIndex(before, ">") || strings.HasPrefix(before[open:], "</") {
+		return "", false
+	}
+	var tag = before[open+1:]
+	var space = strings.LastIndexFunc(tag, unicode.IsSpace)
+	if space < 0 {
+		return "", false
+	}
+	return tag[space+1:], true
+}
+
+func (_self *server) searchDirs(uri string) []string {
+	var dirs = []string{filepath.Dir(uriToPath(uri))}
+	if _self.root != "" {
+		dirs = append(dirs, _self.root)
+	}
+	return dirs
+}
+
+func isComponent(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func uriToPath(uri string) string {
+	var u, err = url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
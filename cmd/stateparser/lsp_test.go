@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// request frames a JSON-RPC message, a request when id is not 0.
+func request(id int, method string, params interface{}) string {
+	var msg = map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id != 0 {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	body, _ := json.Marshal(msg)
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// session runs the server over the messages and returns what it wrote.
+func session(t *testing.T, messages ...string) []*message {
+	t.Helper()
+	var out bytes.Buffer
+	err := runLSP(strings.NewReader(strings.Join(messages, "")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replies = []*message{}
+	var c = newConn(&out, nil)
+	for {
+		msg, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return replies
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, msg)
+	}
+}
+
+func didOpen(uri string, text string) string {
+	return request(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri, "text": text},
+	})
+}
+
+func hoverAt(id int, uri string, line int, character int) string {
+	return request(id, "textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": character},
+	})
+}
+
+// decode converts a result or params to v.
+func decode(t *testing.T, value interface{}, v interface{}) {
+	t.Helper()
+	body, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	var replies = session(t,
+		request(1, "initialize", map[string]string{"rootUri": "file:///tmp"}),
+		request(0, "initialized", map[string]string{}),
+		request(2, "workspace/symbol", map[string]string{}),
+		request(3, "shutdown", nil),
+		request(0, "exit", nil))
+	if len(replies) != 3 {
+		t.Fatalf("got %d replies, want 3", len(replies))
+	}
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	decode(t, replies[0].Result, &result)
+	if result.Capabilities["hoverProvider"] != true {
+		t.Errorf("got capabilities %v, want a hover provider", result.Capabilities)
+	}
+	if replies[1].Error == nil || replies[1].Error.Code != methodNotFound {
+		t.Errorf("got %+v for an unknown method, want method not found", replies[1].Error)
+	}
+	if string(*replies[2].ID) != "3" || replies[2].Error != nil {
+		t.Errorf("got %+v, want the shutdown reply", replies[2])
+	}
+}
+
+// TestInvalidParams checks that a request with params that do not decode
+// gets an InvalidParams error and a notification with them is dropped.
+func TestInvalidParams(t *testing.T) {
+	var replies = session(t,
+		request(1, "initialize", "root"),
+		request(0, "textDocument/didOpen", []int{1}),
+		request(2, "textDocument/hover", 1))
+	if len(replies) != 2 {
+		t.Fatalf("got %d replies, want 2", len(replies))
+	}
+	for _, reply := range replies {
+		if reply.Error == nil || reply.Error.Code != invalidParams {
+			t.Errorf("got %+v, want invalid params", reply)
+		}
+	}
+}
+
+func TestExitBeforeShutdown(t *testing.T) {
+	var out bytes.Buffer
+	err := runLSP(strings.NewReader(request(0, "exit", nil)), &out)
+	if err == nil {
+		t.Error("exit before shutdown succeeds")
+	}
+}
+
+func TestInvalidContentLength(t *testing.T) {
+	var out bytes.Buffer
+	err := runLSP(strings.NewReader("Content-Length: x\r\n\r\n{}"), &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid Content-Length") {
+		t.Errorf("got %v, want invalid Content-Length", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	var replies = session(t,
+		didOpen("file:///a.view.html", "<div>\n  <p on:={ f }></p>\n</div>"),
+		didOpen("file:///b.view.html", "<div></div>"))
+	if len(replies) != 2 {
+		t.Fatalf("got %d messages, want 2", len(replies))
+	}
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+	decode(t, replies[0].Params, &params)
+	if replies[0].Method != "textDocument/publishDiagnostics" || len(params.Diagnostics) != 1 {
+		t.Fatalf("got %s %+v, want one diagnostic", replies[0].Method, params)
+	}
+	var d = params.Diagnostics[0]
+	if d.Range.Start.Line != 1 || !strings.Contains(d.Message, "invalid-directive-name") {
+		t.Errorf("got %+v, want invalid-directive-name on line 1", d)
+	}
+	decode(t, replies[1].Params, &params)
+	if params.URI != "file:///b.view.html" || len(params.Diagnostics) != 0 {
+		t.Errorf("got %+v, want no diagnostics", params)
+	}
+}
+
+func TestHover(t *testing.T) {
+	var uri = "file:///a.view.html"
+	var source = `<p class="a" class:dark={ d } if={ show } style="x">Hi</p>`
+	var tests = []struct {
+		character int
+		want      string
+	}{
+		{4, ""},
+		{14, "`class:name={fn}`"},
+		{31, "`if={fn}`"},
+		{43, ""},
+		{52, ""},
+	}
+	var messages = []string{didOpen(uri, source)}
+	for i, test := range tests {
+		messages = append(messages, hoverAt(i+1, uri, 0, test.character))
+	}
+	var replies = session(t, messages...)
+	if len(replies) != len(tests)+1 {
+		t.Fatalf("got %d messages, want %d", len(replies), len(tests)+1)
+	}
+	for i, test := range tests {
+		var result *hover
+		decode(t, replies[i+1].Result, &result)
+		if test.want == "" {
+			if result != nil {
+				t.Errorf("character %d: got %q, want no hover", test.character, result.Contents.Value)
+			}
+			continue
+		}
+		if result == nil || !strings.HasPrefix(result.Contents.Value, test.want) {
+			t.Errorf("character %d: got %+v, want %s", test.character, result, test.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Command stateparser works with goptos view templates.
+//
+//	stateparser lsp              serve the Language Server Protocol over stdio
+//	stateparser watch [./...]    regenerate views when their templates change
+//
+// A template `counter.view.html` generates `counter.view.go`, declaring
+// `func counterView(cx *Context) *Elem` in the package next to it.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: stateparser <command>
+
+commands:
+  lsp                serve the Language Server Protocol over stdio
+  watch [./...]      regenerate views when their templates change
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "lsp":
+		err = runLSP(os.Stdin, os.Stdout)
+	case "watch":
+		err = runWatch(os.Args[2:], os.Stderr)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "stateparser:", err)
+		os.Exit(1)
+	}
+}
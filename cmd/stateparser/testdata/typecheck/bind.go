@@ -0,0 +1,10 @@
+package view
+
+import "github.com/goptos/system"
+
+type Color string
+
+var name, notes, size system.Signal[string]
+var done system.Signal[bool]
+var age, rating system.Signal[int]
+var colors system.Signal[[]Color]
@@ -0,0 +1,12 @@
+package view
+
+import "github.com/goptos/system"
+
+type Todo struct {
+	Title string
+}
+
+func (_self Todo) Done() bool { return false }
+
+var todo Todo
+var editing system.Signal[bool]
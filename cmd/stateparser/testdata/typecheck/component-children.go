@@ -0,0 +1,23 @@
+package view
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+type CardProps struct {
+	Title          string
+	Header         func(e *system.Elem) *system.Elem
+	Children       func(e *system.Elem) *system.Elem
+	RenderHeader   func(w io.Writer)
+	RenderChildren func(w io.Writer)
+}
+
+type card struct{}
+
+func (_self card) View(cx *system.Context, props CardProps) *system.Elem { return nil }
+func (_self card) Render(w io.Writer, props CardProps)                   {}
+
+var Card card
+var title string
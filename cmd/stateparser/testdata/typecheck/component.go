@@ -0,0 +1,21 @@
+package view
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+type ButtonProps struct {
+	Label   string
+	Primary bool
+	Size    string
+}
+
+type button struct{}
+
+func (_self button) View(cx *system.Context, props ButtonProps) *system.Elem { return nil }
+func (_self button) Render(w io.Writer, props ButtonProps)                   {}
+
+var Button button
+var size string
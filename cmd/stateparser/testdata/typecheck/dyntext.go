@@ -0,0 +1,6 @@
+package view
+
+import "github.com/goptos/system"
+
+var name system.Signal[string]
+var count system.Signal[int]
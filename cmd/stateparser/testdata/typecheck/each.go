@@ -0,0 +1,19 @@
+package view
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+type Todo struct {
+	ID int
+}
+
+type todoItem struct{}
+
+func (_self todoItem) View(cx *system.Context, todo Todo) *system.Elem { return nil }
+func (_self todoItem) Render(w io.Writer, todo Todo)                   {}
+
+var TodoItem todoItem
+var todos system.Signal[[]Todo]
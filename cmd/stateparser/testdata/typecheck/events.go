@@ -0,0 +1,6 @@
+package view
+
+import "github.com/goptos/system"
+
+var save, submit func(e system.Event)
+var count system.Signal[int]
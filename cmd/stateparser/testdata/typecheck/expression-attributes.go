@@ -0,0 +1,8 @@
+package view
+
+import "github.com/goptos/system"
+
+var url system.Signal[string]
+var count system.Signal[int]
+var index int
+var fn func() string
@@ -0,0 +1,15 @@
+package view
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+type login struct{}
+
+func (_self login) View(cx *system.Context) *system.Elem { return nil }
+func (_self login) Render(w io.Writer)                   {}
+
+var Login login
+var loggedIn system.Signal[bool]
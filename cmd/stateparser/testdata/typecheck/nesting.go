@@ -0,0 +1,33 @@
+package view
+
+import (
+	"io"
+
+	"github.com/goptos/system"
+)
+
+type CardProps struct {
+	Title          string
+	Children       func(e *system.Elem) *system.Elem
+	RenderChildren func(w io.Writer)
+}
+
+type card struct{}
+
+func (_self card) View(cx *system.Context, props CardProps) *system.Elem { return nil }
+func (_self card) Render(w io.Writer, props CardProps)                   {}
+
+type item struct{}
+
+func (_self item) View(cx *system.Context, name string) *system.Elem { return nil }
+func (_self item) Render(w io.Writer, name string)                   {}
+
+func itemKey(name string) string { return name }
+
+var Card card
+var Item item
+var title, profile string
+var ready system.Signal[bool]
+var items system.Signal[[]string]
+var count system.Signal[int]
+var muted func() bool
@@ -0,0 +1,5 @@
+package view
+
+import "github.com/goptos/system"
+
+var input system.Signal[*system.Elem]
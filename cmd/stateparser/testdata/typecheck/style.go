@@ -0,0 +1,7 @@
+package view
+
+import "github.com/goptos/system"
+
+var color system.Signal[string]
+var size int
+var width func() string
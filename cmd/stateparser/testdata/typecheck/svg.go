@@ -0,0 +1,3 @@
+package view
+
+var radius int
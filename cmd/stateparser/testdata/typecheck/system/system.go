@@ -0,0 +1,88 @@
+// Package system is a stub of the goptos runtime, imported under its path
+// stateparser.Runtime. It declares the types and methods GoptosBackend
+// generates calls to, so typecheck_test.go can type check the views without
+// the runtime. It is written after the generated code, not after the
+// runtime's source, and must follow the runtime whenever it changes.
+package system
+
+type Context struct{}
+
+type Elem struct{}
+
+type Template struct{}
+
+type Value struct{}
+
+type Event struct{}
+
+type EventOptions struct {
+	Once    bool
+	Capture bool
+	Passive bool
+}
+
+type Signal[T any] struct {
+	value T
+}
+
+func (_self *Elem) New(tag string) *Elem                                     { return _self }
+func (_self *Elem) NewNS(namespace string, tag string) *Elem                 { return _self }
+func (_self *Elem) Attr(name string, value string) *Elem                     { return _self }
+func (_self *Elem) AttrNS(namespace string, name string, value string) *Elem { return _self }
+func (_self *Elem) SpreadAttrs(attrs map[string]string) *Elem                { return _self }
+func (_self *Elem) Text(text string) *Elem                                   { return _self }
+func (_self *Elem) Child(child *Elem) *Elem                                  { return _self }
+
+func (_self *Elem) DynText(cx *Context, effect func() string) *Elem { return _self }
+func (_self *Elem) DynChild(cx *Context, condition func() bool, child *Elem) *Elem {
+	return _self
+}
+func (_self *Elem) DynAttr(cx *Context, effect func() bool, name string, value string) *Elem {
+	return _self
+}
+func (_self *Elem) DynAttrValue(cx *Context, effect func() string, name string) *Elem {
+	return _self
+}
+func (_self *Elem) DynStyle(cx *Context, effect func() string, property string) *Elem {
+	return _self
+}
+func (_self *Elem) DynProp(cx *Context, effect func() any, name string) *Elem { return _self }
+
+func (_self *Elem) HydrateDynText(cx *Context, id string, effect func() string) *Elem {
+	return _self
+}
+func (_self *Elem) HydrateDynChild(cx *Context, id string, condition func() bool, child *Elem) *Elem {
+	return _self
+}
+
+func (_self *Elem) On(event string, handler func(Event)) *Elem { return _self }
+func (_self *Elem) OnWithOptions(event string, handler func(Event), options EventOptions) *Elem {
+	return _self
+}
+
+func (_self *Template) New(html string) *Template { return _self }
+func (_self *Template) Clone() *Elem              { return nil }
+
+func (_self Event) PreventDefault()  {}
+func (_self Event) StopPropagation() {}
+func (_self Event) Key() string      { return "" }
+func (_self Event) Target() Value    { return Value{} }
+
+func (_self Value) Get(name string) Value { return _self }
+func (_self Value) String() string        { return "" }
+func (_self Value) Bool() bool            { return false }
+func (_self Value) Float() float64        { return 0 }
+
+func (_self *Signal[T]) Get() T         { return _self.value }
+func (_self *Signal[T]) Set(value T)    { _self.value = value }
+func (_self *Signal[T]) String() string { return "" }
+
+// Each renders view for every item collect returns as a child of e, key
+// identifies the items between updates.
+func Each[T any, K comparable](e *Elem, cx *Context, collect func() []T, key func(T) K, view func(*Context, T) *Elem) *Elem {
+	return e
+}
+
+func HydrateEach[T any, K comparable](e *Elem, cx *Context, id string, collect func() []T, key func(T) K, view func(*Context, T) *Elem) *Elem {
+	return e
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goptos/stateparser"
+)
+
+// stubImporter imports the stub runtime in testdata/typecheck/system under
+// the import path of the runtime and the standard library from source, so
+// nothing is fetched.
+type stubImporter struct {
+	fset   *token.FileSet
+	std    types.Importer
+	system *types.Package
+}
+
+func newStubImporter(t *testing.T) *stubImporter {
+	var fset = token.NewFileSet()
+	var result = &stubImporter{fset: fset, std: importer.ForCompiler(fset, "source", nil)}
+	system, err := checkPackage(fset, stateparser.Runtime, filepath.Join("testdata", "typecheck", "system"), result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.system = system
+	return result
+}
+
+func (_self *stubImporter) Import(path string) (*types.Package, error) {
+	if path == stateparser.Runtime && _self.system != nil {
+		return _self.system, nil
+	}
+	return _self.std.Import(path)
+}
+
+// checkPackage type checks the Go files in dir as the package path and
+// returns all the type errors at once.
+func checkPackage(fset *token.FileSet, path string, dir string, imports types.Importer) (*types.Package, error) {
+	var names, err = filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files = []*ast.File{}
+	for _, name := range names {
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	var errs = []string{}
+	var config = types.Config{
+		Importer: imports,
+		Error: func(err error) {
+			errs = append(errs, err.Error())
+		},
+	}
+	pkg, _ := config.Check(path, fset, files, nil)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return pkg, nil
+}
+
+// viewOutput compiles template with opts the way generate compiles it into
+// dir.
+func viewOutput(dir string, template string, opts stateparser.Options) (stateparser.Output, error) {
+	source, err := os.ReadFile(template)
+	if err != nil {
+		return stateparser.Output{}, err
+	}
+	var name = strings.TrimSuffix(filepath.Base(template), ".html")
+	opts.StaticPrefix = viewFunc(filepath.Join(dir, name+templateSuffix))
+	return stateparser.Compile(string(source), opts)
+}
+
+// viewPackage writes the file generate writes for output, compiled from
+// template, into a package of its own below dir, next to the declarations of
+// the template from testdata/typecheck.
+func viewPackage(dir string, template string, output stateparser.Output, backend stateparser.Backend) error {
+	var name = strings.TrimSuffix(filepath.Base(template), ".html")
+	declarations, err := os.ReadFile(filepath.Join("testdata", "typecheck", name+".go"))
+	if os.IsNotExist(err) {
+		declarations, err = []byte("package view\n"), nil
+	}
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".go"), declarations, 0o644)
+	if err != nil {
+		return err
+	}
+	var view = filepath.Join(dir, name+templateSuffix)
+	if backend == nil {
+		backend = stateparser.GoptosBackend{}
+	}
+	code, err := generate(view, output, backend)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath(view), code, 0o644)
+}
+
+// TestTypeCheck type checks the files generated for the templates in
+// testdata/golden against the stub runtime, catching generated code that
+// parses but calls the runtime wrongly. The identifiers a template uses are
+// declared in the file of the same name in testdata/typecheck.
+func TestTypeCheck(t *testing.T) {
+	var templates, err = filepath.Glob(filepath.Join("..", "..", "testdata", "golden", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Fatal("no templates in testdata/golden")
+	}
+	var imports = newStubImporter(t)
+	var variants = map[string]stateparser.Options{
+		"plain":          {},
+		"hydrate":        {Hydrate: true},
+		"hoist":          {Hoist: true},
+		"render":         {Backend: stateparser.HTMLBackend{}},
+		"render-hydrate": {Backend: stateparser.HTMLBackend{}, Hydrate: true},
+		"render-hoist":   {Backend: stateparser.HTMLBackend{}, Hoist: true},
+	}
+	for _, template := range templates {
+		for variant, opts := range variants {
+			var name = strings.TrimSuffix(filepath.Base(template), ".html") + "/" + variant
+			t.Run(name, func(t *testing.T) {
+				var dir = t.TempDir()
+				output, err := viewOutput(dir, template, opts)
+				if err != nil {
+					t.Skip("does not compile, covered by TestGolden")
+				}
+				err = viewPackage(dir, template, output, opts.Backend)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = checkPackage(imports.fset, "view", dir, imports)
+				if err != nil {
+					t.Errorf("%s does not type check:\n%s", template, err)
+				}
+			})
+		}
+	}
+}
+
+// swappedBackend generates DynAttr with its condition and class name swapped.
+type swappedBackend struct {
+	stateparser.GoptosBackend
+}
+
+func (swappedBackend) Class(value string, toggles []stateparser.ClassToggle) string {
+	var statement = ""
+	for _, toggle := range toggles {
+		statement = statement + fmt.Sprintf(".DynAttr(cx, \"class\", %s, \"%s\")", toggle.Condition, toggle.Name)
+	}
+	return statement
+}
+
+// TestTypeCheckSwappedArguments makes sure the harness rejects views that
+// parse but pass the runtime its arguments in the wrong order.
+func TestTypeCheckSwappedArguments(t *testing.T) {
+	var dir = t.TempDir()
+	var template = filepath.Join("..", "..", "testdata", "golden", "class.html")
+	output, err := viewOutput(dir, template, stateparser.Options{Backend: swappedBackend{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = viewPackage(dir, template, output, swappedBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var imports = newStubImporter(t)
+	_, err = checkPackage(imports.fset, "view", dir, imports)
+	if err == nil {
+		t.Fatal("the view with swapped DynAttr arguments type checks")
+	}
+}
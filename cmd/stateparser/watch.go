@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goptos/stateparser"
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+type watcher struct {
+	patterns []string
+	interval time.Duration
+	debounce time.Duration
+	stderr   io.Writer
+	hoist    bool
+	cache    *stateparser.Cache
+	files    map[string]fileState
+	pending  map[string]time.Time
+}
+
+// runWatch polls the templates matched by the patterns and regenerates the
+// ones that change. It only returns on bad arguments.
+func runWatch(args []string, stderr io.Writer) error {
+	var flags = flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var interval = flags.Duration("interval", 250*time.Millisecond, "how often templates are polled")
+	var debounce = flags.Duration("debounce", 100*time.Millisecond, "how long a template must be unchanged before it is compiled")
+	var hoist = flags.Bool("hoist", false, "hoist static subtrees into package-level declarations")
+	var cacheDir = flags.String("cache", defaultCacheDir(), "where compiled views are cached, empty to disable the cache")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	var patterns = flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	var w = &watcher{
+		patterns: patterns,
+		interval: *interval,
+		debounce: *debounce,
+		stderr:   stderr,
+		hoist:    *hoist,
+		cache:    nil,
+		files:    make(map[string]fileState),
+		pending:  make(map[string]time.Time),
+	}
+	if *cacheDir != "" {
+		w.cache = stateparser.NewCache(*cacheDir)
+	}
+	templates, err := w.templates()
+	if err != nil {
+		return err
+	}
+	for path, state := range templates {
+		w.files[path] = state
+		w.pending[path] = time.Time{}
+	}
+	for {
+		w.compilePending(time.Now())
+		time.Sleep(w.interval)
+		w.poll(time.Now())
+	}
+}
+
+func defaultCacheDir() string {
+	var dir, err = os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stateparser")
+}
+
+// templates lists the templates matched by the patterns, `dir` matches the
+// templates in dir and `dir/...` the templates in dir and below.
+func (_self *watcher) templates() (map[string]fileState, error) {
+	var result = make(map[string]fileState)
+	for _, pattern := range _self.patterns {
+		var dir, recursive = strings.CutSuffix(pattern, "...")
+		dir = filepath.Clean(dir)
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != dir && (!recursive || strings.HasPrefix(entry.Name(), ".") ||
+					entry.Name() == "vendor" || entry.Name() == "testdata") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, templateSuffix) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			result[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// poll marks new and modified templates as pending and forgets removed
+// ones. Each change restarts the template's debounce.
+func (_self *watcher) poll(now time.Time) {
+	templates, err := _self.templates()
+	if err != nil {
+		fmt.Fprintln(_self.stderr, err)
+		return
+	}
+	for path, state := range templates {
+		if previous, ok := _self.files[path]; ok && previous == state {
+			continue
+		}
+		_self.files[path] = state
+		_self.pending[path] = now
+	}
+	for path := range _self.files {
+		if _, ok := templates[path]; !ok {
+			delete(_self.files, path)
+			delete(_self.pending, path)
+		}
+	}
+}
+
+// compilePending regenerates the pending templates that have not changed
+// for the debounce duration.
+func (_self *watcher) compilePending(now time.Time) {
+	var ready = []string{}
+	for path, changed := range _self.pending {
+		if now.Sub(changed) >= _self.debounce {
+			ready = append(ready, path)
+		}
+	}
+	sort.Strings(ready)
+	var files = []stateparser.File{}
+	for _, path := range ready {
+		delete(_self.pending, path)
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(_self.stderr, err)
+			continue
+		}
+		files = append(files, stateparser.File{
+			Path:   path,
+			Source: string(source),
+			Options: stateparser.Options{
+				Hoist:        _self.hoist,
+				StaticPrefix: viewFunc(path),
+				Cache:        _self.cache,
+			},
+		})
+	}
+	for _, compiled := range stateparser.CompileAll(context.Background(), files) {
+		var err = compiled.Err
+		if err == nil {
+			err = writeView(compiled.Path, compiled.Output)
+		}
+		if err != nil {
+			fmt.Fprintln(_self.stderr, diagnosticText(compiled.Path, err))
+			continue
+		}
+		fmt.Fprintf(_self.stderr, "%s: ok\n", compiled.Path)
+	}
+}
+
+// writeView writes the Go file of template, the file is only written when
+// its content changes so the Go toolchain does not rebuild needlessly.
+func writeView(template string, output stateparser.Output) error {
+	code, err := generate(template, output, stateparser.GoptosBackend{})
+	if err != nil {
+		return err
+	}
+	var path = outputPath(template)
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, code) {
+		return nil
+	}
+	return os.WriteFile(path, code, 0o644)
+}
+
+// diagnosticText prints err as `path:line:column: message` when it has a
+// position.
+func diagnosticText(path string, err error) string {
+	var positioned *tokens.Error
+	if !errors.As(err, &positioned) {
+		return fmt.Sprintf("%s: %s", path, err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s",
+		path,
+		positioned.Position.StartLine,
+		positioned.Position.StartColumn,
+		positioned.Err)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWatcher(dir string, stderr *bytes.Buffer) *watcher {
+	return &watcher{
+		patterns: []string{dir + "/..."},
+		interval: 0,
+		debounce: time.Second,
+		stderr:   stderr,
+		hoist:    true,
+		cache:    nil,
+		files:    make(map[string]fileState),
+		pending:  make(map[string]time.Time),
+	}
+}
+
+func TestWatch(t *testing.T) {
+	var dir = t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var good = filepath.Join(dir, "sub", "good.view.html")
+	var bad = filepath.Join(dir, "bad.view.html")
+	os.WriteFile(good, []byte(`<div><p>Hi</p></div>`), 0o644)
+	os.WriteFile(bad, []byte(`<div on:={ f }></div>`), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.html"), []byte(`<p>`), 0o644)
+
+	var stderr bytes.Buffer
+	var w = newTestWatcher(dir, &stderr)
+	var now = time.Now()
+	w.poll(now)
+	if len(w.pending) != 2 {
+		t.Fatalf("got %d pending templates, want 2", len(w.pending))
+	}
+	w.compilePending(now.Add(time.Second / 2))
+	if stderr.Len() != 0 {
+		t.Fatalf("compiled before the debounce: %s", stderr.String())
+	}
+	w.compilePending(now.Add(time.Second))
+	if len(w.pending) != 0 {
+		t.Errorf("got %d pending templates after compiling, want 0", len(w.pending))
+	}
+	if !strings.Contains(stderr.String(), good+": ok") {
+		t.Errorf("got %s, want %s: ok", stderr.String(), good)
+	}
+	if !strings.Contains(stderr.String(), bad+":1:") {
+		t.Errorf("got %s, want a diagnostic for %s", stderr.String(), bad)
+	}
+	code, err := os.ReadFile(outputPath(good))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(code), "goodViewStatic") || !strings.Contains(string(code), "func goodView(") {
+		t.Errorf("got %s, want goodView and its static", code)
+	}
+	if _, err := os.Stat(outputPath(bad)); err == nil {
+		t.Errorf("%s is generated", outputPath(bad))
+	}
+
+	// An unchanged template is not compiled again, a changed one is.
+	w.poll(now.Add(2 * time.Second))
+	if len(w.pending) != 0 {
+		t.Fatalf("got %d pending templates, want 0", len(w.pending))
+	}
+	os.WriteFile(bad, []byte(`<div on:click={ f }></div>`), 0o644)
+	w.poll(now.Add(3 * time.Second))
+	if _, ok := w.pending[bad]; !ok || len(w.pending) != 1 {
+		t.Fatalf("got pending %v, want only %s", w.pending, bad)
+	}
+	os.Remove(good)
+	w.poll(now.Add(3 * time.Second))
+	if _, ok := w.files[good]; ok {
+		t.Errorf("%s is still watched after it was removed", good)
+	}
+}
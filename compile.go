@@ -0,0 +1,123 @@
+package stateparser
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Directive is a keyword attribute registered with Options. Version is part
+// of the cache key.
+type Directive struct {
+	Name    string
+	Handler DirectiveHandler
+	Version string
+}
+
+// Options configure Compile. The zero value generates goptos builder code
+// with the built-in directives and no cache. StaticPrefix starts the names of
+// the hoisted declarations, views generated into one package need prefixes
+// of their own.
+type Options struct {
+	Backend      Backend
+	Hydrate      bool
+	Hoist        bool
+	StaticPrefix string
+	Directives   []Directive
+	Cache        *Cache
+}
+
+// Output is the code generated for one view.
+type Output struct {
+	Result  string
+	Statics []string
+}
+
+// File is a view template compiled by CompileAll.
+type File struct {
+	Path    string
+	Source  string
+	Options Options
+}
+
+// FileOutput is the result of compiling a File.
+type FileOutput struct {
+	Path   string
+	Output Output
+	Err    error
+}
+
+// Compile generates the code for the view in source. It uses a Parser of
+// its own, so it can be called from any number of goroutines. With a Cache,
+// unchanged views are not compiled again, their diagnostics included.
+func Compile(source string, opts Options) (Output, error) {
+	if opts.Cache == nil || generatorHash() == "" {
+		return compile(source, opts)
+	}
+	var key = opts.Cache.Key(source, opts)
+	if output, diagnostic, ok := opts.Cache.Get(key); ok {
+		return output, diagnostic
+	}
+	output, err := compile(source, opts)
+	opts.Cache.Put(key, output, err)
+	return output, err
+}
+
+func compile(source string, opts Options) (Output, error) {
+	var parser = New()
+	if opts.Backend != nil {
+		parser.Backend = opts.Backend
+	}
+	parser.Hydrate = opts.Hydrate
+	parser.Hoist = opts.Hoist
+	parser.StaticPrefix = opts.StaticPrefix
+	for _, directive := range opts.Directives {
+		parser.RegisterDirective(directive.Name, directive.Handler)
+	}
+	err := parser.ParseView(source)
+	if err != nil {
+		return Output{}, err
+	}
+	return Output{Result: parser.Result, Statics: parser.Statics}, nil
+}
+
+// compileFile compiles one of the files of CompileAll.
+func compileFile(file File) FileOutput {
+	output, err := Compile(file.Source, file.Options)
+	return FileOutput{Path: file.Path, Output: output, Err: err}
+}
+
+// CompileAll compiles files on at most GOMAXPROCS goroutines. The outputs are
+// in the order of files whatever order they finish in. Once ctx is done the
+// files not yet started get its error.
+func CompileAll(ctx context.Context, files []File) []FileOutput {
+	var outputs = make([]FileOutput, len(files))
+	var indexes = make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < min(runtime.GOMAXPROCS(0), len(files)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					outputs[i] = FileOutput{Path: files[i].Path, Err: err}
+					continue
+				}
+				outputs[i] = compileFile(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() == nil {
+			select {
+			case indexes <- i:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		outputs[i] = FileOutput{Path: files[i].Path, Err: ctx.Err()}
+	}
+	close(indexes)
+	wg.Wait()
+	return outputs
+}
@@ -0,0 +1,140 @@
+package stateparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goptos/stateparser/ast/nodes"
+)
+
+// TestCompile checks that Compile generates what a Parser does.
+func TestCompile(t *testing.T) {
+	var source = `<div class:on={ on }><p if={ show }>{ name }</p><p>Hi</p></div>`
+	var parser = New()
+	parser.Hydrate = true
+	parser.Hoist = true
+	err := parser.ParseView(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := Compile(source, Options{Hydrate: true, Hoist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Result != parser.Result || strings.Join(output.Statics, "\n") != strings.Join(parser.Statics, "\n") {
+		t.Errorf("got %+v, want %s %v", output, parser.Result, parser.Statics)
+	}
+}
+
+func TestCompileConcurrently(t *testing.T) {
+	var want = compileView(t, `<p>{ x }</p>`, Options{})
+	var wg sync.WaitGroup
+	var results = make([]string, 64)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := Compile(`<p>{ x }</p>`, Options{})
+			if err != nil {
+				results[i] = err.Error()
+				return
+			}
+			results[i] = strings.TrimSuffix(output.Result, "\r")
+		}()
+	}
+	wg.Wait()
+	for i, got := range results {
+		if got != want {
+			t.Errorf("compile %d: got %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestCompileAll(t *testing.T) {
+	var files = []File{}
+	for i := 0; i < 100; i++ {
+		var source = fmt.Sprintf(`<p id="%d"></p>`, i)
+		if i%10 == 0 {
+			source = `<p on:={ f }></p>`
+		}
+		files = append(files, File{Path: fmt.Sprintf("%d.view.html", i), Source: source})
+	}
+	var outputs = CompileAll(context.Background(), files)
+	if len(outputs) != len(files) {
+		t.Fatalf("got %d outputs, want %d", len(outputs), len(files))
+	}
+	for i, output := range outputs {
+		if output.Path != files[i].Path {
+			t.Fatalf("output %d is %s, want %s", i, output.Path, files[i].Path)
+		}
+		if i%10 == 0 {
+			if output.Err == nil {
+				t.Errorf("%s: got %s, want an error", output.Path, output.Output.Result)
+			}
+			continue
+		}
+		var want = fmt.Sprintf(`(*Elem).New(nil, "p").Attr("id", "%d")`, i)
+		if output.Err != nil || strings.TrimSuffix(output.Output.Result, "\r") != want {
+			t.Errorf("%s: got %s %v, want %s", output.Path, output.Output.Result, output.Err, want)
+		}
+	}
+}
+
+func TestCompileAllOptions(t *testing.T) {
+	var outputs = CompileAll(context.Background(), []File{
+		{Path: "a", Source: `<p></p>`, Options: Options{Backend: HTMLBackend{}}},
+		{Path: "b", Source: `<p></p>`},
+	})
+	if !strings.HasPrefix(outputs[0].Output.Result, "io.WriteString") ||
+		!strings.HasPrefix(outputs[1].Output.Result, "(*Elem)") {
+		t.Errorf("got %s and %s, want each file compiled with its options",
+			outputs[0].Output.Result, outputs[1].Output.Result)
+	}
+}
+
+func TestCompileAllCancelled(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	var files = []File{{Path: "a", Source: `<p></p>`}, {Path: "b", Source: `<p></p>`}}
+	for _, output := range CompileAll(ctx, files) {
+		if !errors.Is(output.Err, context.Canceled) {
+			t.Errorf("%s: got %v, want context.Canceled", output.Path, output.Err)
+		}
+	}
+	if outputs := CompileAll(context.Background(), nil); len(outputs) != 0 {
+		t.Errorf("got %d outputs for no files", len(outputs))
+	}
+}
+
+// TestCompileAllCancelledMidway cancels ctx while one file is compiled, on
+// one goroutine none of the files after it may be compiled.
+func TestCompileAllCancelledMidway(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var stop = Directive{Name: "stop", Handler: func(node nodes.Node, value string, builder *Builder) error {
+		cancel()
+		return nil
+	}}
+	var files = []File{}
+	for i := 0; i < 20; i++ {
+		var source = `<p></p>`
+		if i == 5 {
+			source = `<p stop={ true }></p>`
+		}
+		files = append(files, File{Path: fmt.Sprint(i), Source: source, Options: Options{Directives: []Directive{stop}}})
+	}
+	for i, output := range CompileAll(ctx, files) {
+		if i <= 5 && output.Err != nil {
+			t.Errorf("%s: got %v, want it compiled", output.Path, output.Err)
+		}
+		if i > 5 && !errors.Is(output.Err, context.Canceled) {
+			t.Errorf("%s: got %q %v, want context.Canceled", output.Path, output.Output.Result, output.Err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package stateparser
+
+import (
+	"fmt"
+	"go/parser"
+	"strings"
+
+	"github.com/goptos/stateparser/ast/nodes"
+)
+
+// DirectiveHandler is called for every element or component carrying the
+// keyword attribute it was registered under, with the attribute's value.
+// It decides how the element is built through the Builder.
+type DirectiveHandler func(node nodes.Node, value string, builder *Builder) error
+
+// Builder exposes the statement of the element a directive is applied to.
+type Builder struct {
+	parser    *Parser
+	directive string
+}
+
+// Backend returns the Backend the parser generates code with.
+func (_self *Builder) Backend() Backend {
+	return _self.parser.Backend
+}
+
+// RegionID returns a new hydration ID for a region of the element, empty
+// unless the parser hydrates. Directives that make the element a dynamic
+// region pass it on to the Backend. The first region of an element is
+// identified by its path, every further one adds the name of its directive,
+// `<ul each={cF} key={kF} if={fn}>` => `0.1` and `0.1.if`.
+func (_self *Builder) RegionID() string {
+	var nodeInfo = _self.parser.popNodeInfo()
+	var path = nodeInfo.path
+	if nodeInfo.regions > 0 {
+		path = path + "." + _self.directive
+	}
+	nodeInfo.regions++
+	_self.parser.nodeInfo.Push(nodeInfo)
+	return _self.parser.regionID(path)
+}
+
+// Wrap surrounds the element's statement, `prefix(*Elem).New(nil, "div")suffix`.
+func (_self *Builder) Wrap(prefix string, suffix string) {
+	_self.parser.prependToStatement("%s", prefix)
+	_self.parser.appendToStatement("%s", suffix)
+}
+
+// Transform replaces the element's statement with transform(statement).
+func (_self *Builder) Transform(transform func(statement string) string) {
+	_self.parser.newStatement("%s", transform(_self.parser.popStatement()))
+}
+
+// Finish replaces the element's statement with transform(statement) once its
+// attributes and children have been added, before the element is closed.
+func (_self *Builder) Finish(transform func(statement string) string) {
+	var nodeInfo = _self.parser.popNodeInfo()
+	nodeInfo.finish = append(nodeInfo.finish, transform)
+	_self.parser.nodeInfo.Push(nodeInfo)
+}
+
+// Append adds to the end of the element's statement, `.Tooltip("Save")`.
+func (_self *Builder) Append(s string, args ...interface{}) {
+	_self.parser.appendToStatement(s, args...)
+}
+
+// Attach replaces the `.Child(statement)` call that adds the element to its
+// parent, `.DynChild(cx, fn, statement)`. Directives that attach cannot be
+// used on the root element.
+func (_self *Builder) Attach(attach func(statement string) string) {
+	var nodeInfo = _self.parser.popNodeInfo()
+	nodeInfo.attach = attach
+	nodeInfo.attachDirective = _self.directive
+	_self.parser.nodeInfo.Push(nodeInfo)
+}
+
+// KeywordValue returns the value of the keyword attribute called name on node.
+func KeywordValue(node nodes.Node, name string) (string, bool) {
+	for _, childNode := range node.GetChildren() {
+		if childNode.GetType() == nodes.KeywordAttribute && childNode.GetName() == name {
+			return strings.TrimSpace(childNode.GetEffect()), true
+		}
+	}
+	return "", false
+}
+
+// RegisterDirective makes name a keyword attribute handled by handler.
+// Directives are applied in the order they were registered, registering a
+// name again replaces its handler.
+func (_self *Parser) RegisterDirective(name string, handler DirectiveHandler) {
+	if _, ok := _self.directives[name]; !ok {
+		_self.directiveNames = append(_self.directiveNames, name)
+	}
+	_self.directives[name] = handler
+}
+
+// DirectiveNames returns the registered keyword attribute names in the
+// order their directives are applied.
+func (_self *Parser) DirectiveNames() []string {
+	return append([]string{}, _self.directiveNames...)
+}
+
+// applyDirectives runs the handlers for the keyword attributes of node
+// against the statement at the top of the stack.
+func (_self *Parser) applyDirectives(node nodes.Node) error {
+	for _, name := range _self.directiveNames {
+		value, ok := KeywordValue(node, name)
+		if !ok {
+			continue
+		}
+		err := _self.directives[name](node, value, &Builder{parser: _self, directive: name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expression checks that the value of the directive called name is a Go
+// expression. The value of a keyword attribute may be quoted, `if="show"`,
+// so it is not always code in braces.
+func expression(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s has no Go expression", name)
+	}
+	_, err := parser.ParseExpr(value)
+	if err != nil {
+		return fmt.Errorf("%s={%s} is not a Go expression: %v", name, value, err)
+	}
+	return nil
+}
+
+func (_self *Parser) registerBuiltinDirectives() {
+	/*
+		`<input ref={input} />` =>
+		`func() *Elem { e := (*Elem).New(nil, "input"); input.Set(e); return e }()`
+	*/
+	_self.RegisterDirective("ref", func(node nodes.Node, value string, builder *Builder) error {
+		err := expression("ref", value)
+		if err != nil {
+			return err
+		}
+		if node.GetType() == nodes.Component {
+			return fmt.Errorf("ref is not supported on components, <%s ref={%s}>", node.GetName(), value)
+		}
+		builder.Transform(func(statement string) string {
+			return builder.Backend().Ref(statement, value)
+		})
+		return nil
+	})
+	/*
+		`<ul each={cF} key={kF}><Li /></ul>` =>
+		`system.Each((*Elem).New(nil, "ul"), cx, cF, kF, Li.View)`
+	*/
+	_self.RegisterDirective("each", func(node nodes.Node, value string, builder *Builder) error {
+		err := expression("each", value)
+		if err != nil {
+			return err
+		}
+		if node.GetType() == nodes.Component {
+			return fmt.Errorf("each is not supported on components, <%s each={%s}>", node.GetName(), value)
+		}
+		var key, ok = KeywordValue(node, "key")
+		if !ok {
+			return fmt.Errorf("<%s each={%s}> needs a key attribute", node.GetName(), value)
+		}
+		var viewComponent = ""
+		for _, childNode := range node.GetChildren() {
+			if childNode.GetType() == nodes.Component && childNode.GetIsSelfClosing() {
+				viewComponent = childNode.GetName()
+			}
+		}
+		if viewComponent == "" {
+			return fmt.Errorf("<%s each={%s}> needs a self-closing component to render each item", node.GetName(), value)
+		}
+		var nodeInfo = _self.popNodeInfo()
+		nodeInfo.eachView = viewComponent
+		_self.nodeInfo.Push(nodeInfo)
+		var id = builder.RegionID()
+		builder.Finish(func(statement string) string {
+			return builder.Backend().List(id, statement, value, key, viewComponent)
+		})
+		return nil
+	})
+	_self.RegisterDirective("key", func(node nodes.Node, value string, builder *Builder) error {
+		err := expression("key", value)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	/*
+		`<p if={fn}>` => `.DynChild(cx, fn, (*Elem).New(nil, "p"))`
+	*/
+	_self.RegisterDirective("if", func(node nodes.Node, value string, builder *Builder) error {
+		err := expression("if", value)
+		if err != nil {
+			return err
+		}
+		var id = builder.RegionID()
+		builder.Attach(func(statement string) string {
+			return builder.Backend().Conditional(id, value, statement)
+		})
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package stateparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goptos/stateparser/ast/nodes"
+)
+
+func TestDirectives(t *testing.T) {
+	var directives = []Directive{
+		{Name: "tooltip", Handler: func(node nodes.Node, value string, builder *Builder) error {
+			builder.Append(".Tooltip(%s)", value)
+			return nil
+		}},
+		{Name: "boxed", Handler: func(node nodes.Node, value string, builder *Builder) error {
+			builder.Wrap("Box(", ")")
+			return nil
+		}},
+		{Name: "traced", Handler: func(node nodes.Node, value string, builder *Builder) error {
+			builder.Transform(func(statement string) string {
+				return fmt.Sprintf("Trace(%s, %s)", value, statement)
+			})
+			return nil
+		}},
+		{Name: "sealed", Handler: func(node nodes.Node, value string, builder *Builder) error {
+			builder.Finish(func(statement string) string {
+				return fmt.Sprintf("Seal(%s)", statement)
+			})
+			return nil
+		}},
+		{Name: "lazy", Handler: func(node nodes.Node, value string, builder *Builder) error {
+			builder.Attach(func(statement string) string {
+				return fmt.Sprintf(".Lazy(%s, %s)", value, statement)
+			})
+			return nil
+		}},
+	}
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<p tooltip={ "Save" }></p>`, `(*Elem).New(nil, "p").Tooltip("Save")`},
+		{`<p boxed>Hi</p>`, "Box((*Elem).New(nil, \"p\")).Text(`Hi`)"},
+		{`<p traced={ "p" }></p>`, `Trace("p", (*Elem).New(nil, "p"))`},
+		{`<p sealed>Hi</p>`, "Seal((*Elem).New(nil, \"p\").Text(`Hi`))"},
+		{`<Card sealed />`, `Seal(Card.View(cx))`},
+		{`<Card sealed><p></p></Card>`,
+			`Seal(Card.View(cx, CardProps{Children: func(e *Elem) *Elem { return e.Child((*Elem).New(nil, "p")) }}))`},
+		{`<div><p lazy={ ready }></p></div>`, `(*Elem).New(nil, "div").Lazy(ready, (*Elem).New(nil, "p"))`},
+		{`<p sealed tooltip={ "Save" }></p>`, `Seal((*Elem).New(nil, "p").Tooltip("Save"))`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{Directives: directives})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestRegisterDirective(t *testing.T) {
+	var parser = New()
+	var handler = func(node nodes.Node, value string, builder *Builder) error {
+		builder.Append(".First()")
+		return nil
+	}
+	parser.RegisterDirective("first", handler)
+	parser.RegisterDirective("if", handler)
+	var names = strings.Join(parser.DirectiveNames(), " ")
+	if names != "ref each key if first" {
+		t.Errorf("got %s, want ref each key if first", names)
+	}
+	err := parser.ParseView(`<p if={ show }></p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = `(*Elem).New(nil, "p").First()`
+	if got := strings.TrimSuffix(parser.Result, "\r"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDirectiveErrors(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<ul each={ items }><Li /></ul>`, "needs a key attribute"},
+		{`<ul each={ items } key={ k }><li></li></ul>`, "needs a self-closing component"},
+		{`<Li each={ items } key={ k } />`, "each is not supported on components"},
+		{`<List each={ items } key={ k }><Li /></List>`, "each is not supported on components"},
+		{`<div><p if></p></div>`, "if has no Go expression"},
+		{`<div><input ref={ } /></div>`, "ref has no Go expression"},
+		{`<ul each key={ k }><Li /></ul>`, "each has no Go expression"},
+		{`<ul each={ items } key><Li /></ul>`, "key has no Go expression"},
+		{`<div><p if="!"></p></div>`, "if={!} is not a Go expression"},
+	}
+	for _, test := range tests {
+		var err = compileError(t, test.source, Options{})
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %s", test.source, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package stateparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// https://developer.mozilla.org/en-US/docs/Web/API/UI_Events/Keyboard_event_key_values
+var keyModifiers = map[string]string{
+	"enter":     "Enter",
+	"tab":       "Tab",
+	"space":     " ",
+	"esc":       "Escape",
+	"escape":    "Escape",
+	"up":        "ArrowUp",
+	"down":      "ArrowDown",
+	"left":      "ArrowLeft",
+	"right":     "ArrowRight",
+	"delete":    "Delete",
+	"backspace": "Backspace",
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/EventTarget/addEventListener#options
+var optionModifiers = map[string]string{
+	"once":    "Once",
+	"capture": "Capture",
+	"passive": "Passive",
+}
+
+// eventStatement builds the `.On(...)` call for an `on:event|modifier...`
+// directive. Key filters, preventDefault and stopPropagation wrap the handler,
+// once, capture and passive become listener options.
+func eventStatement(event string, modifiers []string, handler string) (string, error) {
+	var keys = []string{}
+	var calls = []string{}
+	var options = []string{}
+	for _, modifier := range modifiers {
+		if key, ok := keyModifiers[modifier]; ok {
+			keys = append(keys, fmt.Sprintf("k != %q", key))
+			continue
+		}
+		if option, ok := optionModifiers[modifier]; ok {
+			options = append(options, option+": true")
+			continue
+		}
+		switch modifier {
+		case "preventDefault":
+			calls = append(calls, "e.PreventDefault(); ")
+		case "stopPropagation":
+			calls = append(calls, "e.StopPropagation(); ")
+		default:
+			return "", fmt.Errorf("unknown event modifier on:%s|%s", event, modifier)
+		}
+	}
+	if len(keys) > 0 || len(calls) > 0 {
+		var guard = ""
+		if len(keys) > 0 {
+			guard = fmt.Sprintf("if k := e.Key(); %s { return }; ", strings.Join(keys, " && "))
+		}
+		handler = fmt.Sprintf("func(e Event) { %s%s(%s)(e) }",
+			guard,
+			strings.Join(calls, ""),
+			handler)
+	}
+	if len(options) > 0 {
+		return fmt.Sprintf(".OnWithOptions(%q, %s, EventOptions{%s})",
+			event,
+			handler,
+			strings.Join(options, ", ")), nil
+	}
+	return fmt.Sprintf(".On(%q, %s)", event, handler), nil
+}
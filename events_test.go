@@ -0,0 +1,42 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventStatement(t *testing.T) {
+	var tests = []struct {
+		event     string
+		modifiers []string
+		want      string
+	}{
+		{"click", nil, `.On("click", f)`},
+		{"submit", []string{"preventDefault"},
+			`.On("submit", func(e Event) { e.PreventDefault(); (f)(e) })`},
+		{"click", []string{"stopPropagation", "preventDefault"},
+			`.On("click", func(e Event) { e.StopPropagation(); e.PreventDefault(); (f)(e) })`},
+		{"keydown", []string{"enter", "esc"},
+			`.On("keydown", func(e Event) { if k := e.Key(); k != "Enter" && k != "Escape" { return }; (f)(e) })`},
+		{"scroll", []string{"once", "passive"},
+			`.OnWithOptions("scroll", f, EventOptions{Once: true, Passive: true})`},
+		{"keyup", []string{"space", "capture"},
+			`.OnWithOptions("keyup", func(e Event) { if k := e.Key(); k != " " { return }; (f)(e) }, EventOptions{Capture: true})`},
+	}
+	for _, test := range tests {
+		got, err := eventStatement(test.event, test.modifiers, "f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("on:%s|%s\ngot:  %s\nwant: %s", test.event, strings.Join(test.modifiers, "|"), got, test.want)
+		}
+	}
+}
+
+func TestEventErrors(t *testing.T) {
+	var err = compileError(t, `<div on:click|twice={ f }></div>`, Options{})
+	if !strings.Contains(err.Error(), "unknown event modifier on:click|twice") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package stateparser
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// goldenOutput is what a golden file holds for source, the generated code or
+// the positioned error.
+func goldenOutput(source string, opts Options) []byte {
+	var output, err = Compile(source, opts)
+	if err == nil {
+		return []byte(strings.TrimSuffix(output.Result, "\r") + "\n")
+	}
+	var positioned *tokens.Error
+	if errors.As(err, &positioned) {
+		return []byte(fmt.Sprintf("error %d:%d: %s\n",
+			positioned.Position.StartLine,
+			positioned.Position.StartColumn,
+			positioned.Err))
+	}
+	return []byte(fmt.Sprintf("error: %s\n", err))
+}
+
+// TestGolden generates every template in testdata/golden and compares the
+// result with the .golden file next to it, and the HTMLBackend's result with
+// the .render.golden file. Run `go test -run TestGolden -update` to rewrite
+// the golden files after an intended change.
+func TestGolden(t *testing.T) {
+	var templates, err = filepath.Glob(filepath.Join("testdata", "golden", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Fatal("no templates in testdata/golden")
+	}
+	var backends = map[string]Options{
+		".golden":        {},
+		".render.golden": {Backend: HTMLBackend{}},
+	}
+	for _, template := range templates {
+		for suffix, opts := range backends {
+			var golden = strings.TrimSuffix(template, ".html") + suffix
+			t.Run(filepath.Base(golden), func(t *testing.T) {
+				source, err := os.ReadFile(template)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got = goldenOutput(string(source), opts)
+				if *update {
+					err := os.WriteFile(golden, got, 0o644)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s differs from %s\ngot:\n%s\nwant:\n%s", template, golden, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package stateparser
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/goptos/stateparser/lexer"
+)
+
+// HTMLBackend renders a view once to static HTML, for server-side rendering
+// and emails. The Result is the body of a function writing to `w io.Writer`,
+//
+//	func (Button) Render(w io.Writer, p ButtonProps) { <Result> }
+//
+// DynText, dynamic attributes and `if` are evaluated once, `each` is expanded
+// with the item component's Render method and events are dropped. The
+// children of a component are passed as `func(w io.Writer)` in the field
+// SlotProp names. The generated code uses the io, html, fmt and sort
+// packages.
+//
+// When the Parser hydrates, every dynamic region is surrounded by comments
+// carrying its ID, `<!--t:0.1-->` and `<!--/t:0.1-->` for DynText, `c:` for
+// `if` and `e:` for the items of `each`.
+type HTMLBackend struct{}
+
+// writeStatement writes the constant s.
+func writeStatement(s string) string {
+	return fmt.Sprintf("io.WriteString(w, %q)", s)
+}
+
+// regionStatement surrounds statement with the hydration markers of region
+// id, statement is returned unchanged when id is empty.
+func regionStatement(kind string, id string, statement string) string {
+	if id == "" {
+		return statement
+	}
+	return fmt.Sprintf("; %s%s; %s",
+		writeStatement(fmt.Sprintf("<!--%s:%s-->", kind, id)),
+		statement,
+		writeStatement(fmt.Sprintf("<!--/%s:%s-->", kind, id)))
+}
+
+// valueExpression converts an effect to an escaped string following the
+// rule of valueEffect, functions are called and other values formatted.
+func valueExpression(effect string) string {
+	return fmt.Sprintf("html.EscapeString((%s)())", valueEffect(effect))
+}
+
+// attributeValue escapes the static value of an attribute.
+func attributeValue(value string) string {
+	return strings.ReplaceAll(value, "\"", "&quot;")
+}
+
+// `<div>` => `io.WriteString(w, "<div")`
+func (HTMLBackend) Element(namespace string, name string) string {
+	return writeStatement("<" + name)
+}
+
+func (HTMLBackend) OpenElement() string {
+	return "; " + writeStatement(">")
+}
+
+// `</div>` => `; io.WriteString(w, "</div>")`, void elements such as `<input>`
+// have no end tag.
+func (HTMLBackend) CloseElement(namespace string, name string) string {
+	if lexer.IsVoidElement(name) && namespace == "" {
+		return ""
+	}
+	return "; " + writeStatement("</"+name+">")
+}
+
+// `<p>Hi</p>` => `io.WriteString(w, static0a1b2c3d)`
+func (HTMLBackend) Static(name string) string {
+	return fmt.Sprintf("io.WriteString(w, %s)", name)
+}
+
+// `const static0a1b2c3d = "<p>Hi</p>"`
+func (HTMLBackend) StaticDeclaration(name string, html string) string {
+	return fmt.Sprintf("const %s = %q", name, html)
+}
+
+// `<Button label="Save" />` => `Button.Render(w, ButtonProps{Label: "Save"})`
+func (HTMLBackend) Component(name string, props []string, spread string) string {
+	return componentStatement(name, "Render", "w", props, spread)
+}
+
+// `<p>Hi</p>` inside a component => `func(w io.Writer) {; io.WriteString(w, "<p") ... }`
+func (HTMLBackend) ChildBuilder() string {
+	return "func(w io.Writer) {"
+}
+
+func (HTMLBackend) EndChildBuilder(statement string) string {
+	return statement + " }"
+}
+
+// The children are passed in a field of their own, `<slot:header>` =>
+// `RenderHeader`, so that the props of a component can be rendered by both
+// backends.
+func (HTMLBackend) SlotProp(field string) string {
+	return "Render" + field
+}
+
+func (HTMLBackend) Child(statement string) string {
+	return "; " + statement
+}
+
+// `<p if={fn}>` => `; if (fn)() { io.WriteString(w, "<p") ... }`
+func (HTMLBackend) Conditional(id string, condition string, statement string) string {
+	return regionStatement("c", id, fmt.Sprintf("; if (%s)() { %s }", condition, statement))
+}
+
+// `<ul each={cF} key={kF}><Li /></ul>` =>
+// `io.WriteString(w, "<ul"); io.WriteString(w, ">"); for _, item := range (cF)() { Li.Render(w, item) }`
+func (HTMLBackend) List(id string, statement string, collect string, key string, view string) string {
+	return statement + regionStatement("e", id,
+		fmt.Sprintf("; for _, item := range (%s)() { %s.Render(w, item) }", collect, view))
+}
+
+// References only exist in the browser.
+func (HTMLBackend) Ref(statement string, holder string) string {
+	return statement
+}
+
+// `Hello` => `; io.WriteString(w, "Hello")`
+func (HTMLBackend) Text(text string) string {
+	return "; " + writeStatement(text)
+}
+
+// `{count.Get()}` => `; io.WriteString(w, html.EscapeString((func() string {...})()))`
+func (HTMLBackend) DynText(id string, effect string) string {
+	return regionStatement("t", id, fmt.Sprintf("; io.WriteString(w, %s)", valueExpression(effect)))
+}
+
+// `id="sub-button"` => `; io.WriteString(w, " id=\"sub-button\"")`
+func (HTMLBackend) Attr(namespace string, name string, value string) string {
+	if value == "" {
+		return "; " + writeStatement(" "+name)
+	}
+	return "; " + writeStatement(fmt.Sprintf(" %s=\"%s\"", name, attributeValue(value)))
+}
+
+// `href={url}` => `; io.WriteString(w, " href=\""+html.EscapeString((func() string {...})())+"\"")`
+func (HTMLBackend) DynAttrValue(effect string, name string) string {
+	return fmt.Sprintf("; io.WriteString(w, %q+%s+%q)",
+		fmt.Sprintf(" %s=\"", name),
+		valueExpression(effect),
+		"\"")
+}
+
+// `class="item" class:dark={fn}` =>
+// `; io.WriteString(w, " class=\"item"); if (fn)() { io.WriteString(w, " dark") }; io.WriteString(w, "\"")`
+func (HTMLBackend) Class(value string, toggles []ClassToggle) string {
+	if len(toggles) == 0 {
+		return "; " + writeStatement(fmt.Sprintf(" class=\"%s\"", attributeValue(value)))
+	}
+	var statement = "; " + writeStatement(fmt.Sprintf(" class=\"%s", attributeValue(value)))
+	for _, toggle := range toggles {
+		statement = statement + fmt.Sprintf("; if (%s)() { %s }", toggle.Condition, writeStatement(" "+toggle.Name))
+	}
+	return statement + "; " + writeStatement("\"")
+}
+
+// `style="margin: 0" style:color={fn}` =>
+// `; io.WriteString(w, " style=\"margin: 0; color: "+html.EscapeString(...)+"\"")`
+func (HTMLBackend) Style(value string, properties []StyleProperty) string {
+	var parts = []string{}
+	value = strings.TrimRight(strings.TrimSpace(value), "; ")
+	if value != "" {
+		parts = append(parts, fmt.Sprintf("%q", attributeValue(value)))
+	}
+	for _, property := range properties {
+		var prefix = property.Name + ": "
+		if len(parts) > 0 {
+			prefix = "; " + prefix
+		}
+		parts = append(parts, fmt.Sprintf("%q", prefix), valueExpression(property.Value))
+	}
+	return fmt.Sprintf("; io.WriteString(w, \" style=\\\"\"+%s+\"\\\"\")", strings.Join(parts, "+"))
+}
+
+// `{...attrs}` => the entries of the map attrs in the order of their keys,
+// `; { m := (attrs); keys := ...; sort.Strings(keys); for _, k := range keys { ... } }`
+func (HTMLBackend) SpreadAttrs(effect string) string {
+	var m = freeName("m", effect)
+	var keys = freeName("keys", effect)
+	var k = freeName("k", effect)
+	return fmt.Sprintf("; { %s := (%s); %s := make([]string, 0, len(%s)); for %s := range %s { %s = append(%s, %s) }; sort.Strings(%s); "+
+		"for _, %s := range %s { io.WriteString(w, \" \"+html.EscapeString(%s)+\"=\\\"\"+%s+\"\\\"\") } }",
+		m, effect, keys, m, k, m, keys, keys, k, keys,
+		k, keys, k, valueExpression(m+"["+k+"]"))
+}
+
+// `bind:value={name}` on `<input>` => the current value of name as the value
+// attribute, `bind:checked` and `bind:group` as the checked attribute, a
+// checkbox group is checked while its value is in the signal.
+// A bound `<select>` marks its option selected, see Selected, and a bound
+// `<textarea>` value is left to the client.
+func (HTMLBackend) Bind(property string, signal string, element string, attributes map[string]string) (string, error) {
+	_, err := bindStatement(property, signal, element, attributes)
+	if err != nil {
+		return "", err
+	}
+	var value = html.UnescapeString(attributes["value"])
+	switch {
+	case property == "value" && element == "input":
+		return fmt.Sprintf("; io.WriteString(w, \" value=\\\"\"+%s+\"\\\"\")",
+			valueExpression(signal+".Get()")), nil
+	case property == "checked":
+		return fmt.Sprintf("; if %s.Get() { %s }", signal, writeStatement(" checked")), nil
+	case property == "group" && strings.ToLower(attributes["type"]) == "checkbox":
+		var v = freeName("v", signal)
+		return fmt.Sprintf("; for _, %s := range %s.Get() { if %s == %q { %s; break } }",
+			v, signal, v, value, writeStatement(" checked")), nil
+	case property == "group":
+		return fmt.Sprintf("; if %s.Get() == %q { %s }", signal, value, writeStatement(" checked")), nil
+	}
+	return "", nil
+}
+
+// `<option value="a">` in `<select bind:value={choice}>` =>
+// `; if fmt.Sprint(choice.Get()) == "a" { io.WriteString(w, " selected") }`
+func (HTMLBackend) Selected(signal string, value string) string {
+	return fmt.Sprintf("; if fmt.Sprint(%s.Get()) == %q { %s }", signal, value, writeStatement(" selected"))
+}
+
+// Events are dropped, their modifiers are still checked.
+func (HTMLBackend) Event(event string, modifiers []string, handler string) (string, error) {
+	_, err := eventStatement(event, modifiers, handler)
+	return "", err
+}
+
+// `func counterView(w io.Writer) { ... }`
+func (HTMLBackend) ViewDeclaration(name string, statement string) string {
+	return fmt.Sprintf("func %s(w io.Writer) {\n\t%s\n}", name, statement)
+}
+
+func (HTMLBackend) Imports() []string {
+	return []string{`"fmt"`, `"html"`, `"io"`, `"sort"`}
+}
+
+func (HTMLBackend) CacheKey() string {
+	return ""
+}
@@ -0,0 +1,55 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLAttributes(t *testing.T) {
+	var value = func(effect string) string {
+		return "html.EscapeString((" + valueEffect(effect) + ")())"
+	}
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<p class="a" class:dark={ d } id="x" class:big={ b }></p>`,
+			`io.WriteString(w, "<p"); io.WriteString(w, " class=\"a"); if (d)() { io.WriteString(w, " dark") }; if (b)() { io.WriteString(w, " big") }; io.WriteString(w, "\""); io.WriteString(w, " id=\"x\""); io.WriteString(w, ">"); io.WriteString(w, "</p>")`},
+		{`<p class:dark={ d } class="a"></p>`,
+			`io.WriteString(w, "<p"); io.WriteString(w, " class=\"a"); if (d)() { io.WriteString(w, " dark") }; io.WriteString(w, "\""); io.WriteString(w, ">"); io.WriteString(w, "</p>")`},
+		{`<p style="margin: 0;" style:color={ color.Get }></p>`,
+			`io.WriteString(w, "<p"); io.WriteString(w, " style=\""+"margin: 0"+"; color: "+` + value("color.Get") + `+"\""); io.WriteString(w, ">"); io.WriteString(w, "</p>")`},
+		{`<a href={ url.Get }></a>`,
+			`io.WriteString(w, "<a"); io.WriteString(w, " href=\""+` + value("url.Get") + `+"\""); io.WriteString(w, ">"); io.WriteString(w, "</a>")`},
+		{`<p>{ count.Get }</p>`,
+			`io.WriteString(w, "<p"); io.WriteString(w, ">"); io.WriteString(w, ` + value("count.Get") + `); io.WriteString(w, "</p>")`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{Backend: HTMLBackend{}})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestHTMLSpread(t *testing.T) {
+	var got = compileView(t, `<p { ...m }></p>`, Options{Backend: HTMLBackend{}})
+	for _, want := range []string{
+		"m1 := (m)",
+		"sort.Strings(keys)",
+		`html.EscapeString(k)`,
+		"any(m1[k])",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %s, want it to contain %s", got, want)
+		}
+	}
+}
+
+func TestHTMLSlots(t *testing.T) {
+	var got = compileView(t, `<Card><slot:header>Head</slot:header><p></p></Card>`, Options{Backend: HTMLBackend{}})
+	var want = `Card.Render(w, CardProps{RenderHeader: func(w io.Writer) {; io.WriteString(w, "Head") }, RenderChildren: func(w io.Writer) {; io.WriteString(w, "<p"); io.WriteString(w, ">"); io.WriteString(w, "</p>") }})`
+	if got != want {
+		t.Errorf("got:  %s\nwant: %s", got, want)
+	}
+}
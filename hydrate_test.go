@@ -0,0 +1,50 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHydrate(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   []string
+	}{
+		{`<p>Hi { name }</p>`, []string{`.HydrateDynText(cx, "0.1", `}},
+		{`<div><p></p><p if={ show }>{ name }</p></div>`,
+			[]string{`.HydrateDynChild(cx, "0.1", show, `, `.HydrateDynText(cx, "0.1.0", `}},
+		{`<div><ul each={ items } key={ k }><Li /></ul></div>`,
+			[]string{`system.HydrateEach((*Elem).New(nil, "ul"), cx, "0.0", items, k, Li.View)`}},
+		{`<div><ul each={ items } key={ k } if={ show }><Li /></ul></div>`,
+			[]string{`.HydrateDynChild(cx, "0.0.if", show, system.HydrateEach((*Elem).New(nil, "ul"), cx, "0.0", `}},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{Hydrate: true})
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s\ngot:  %s\nwant it to contain: %s", test.source, got, want)
+			}
+		}
+	}
+}
+
+func TestHydrateHTML(t *testing.T) {
+	var got = compileView(t, `<div><ul each={ items } key={ k } if={ show }><Li /></ul>{ name }</div>`,
+		Options{Backend: HTMLBackend{}, Hydrate: true})
+	for _, want := range []string{
+		`"<!--c:0.0.if-->"`, `"<!--/c:0.0.if-->"`,
+		`"<!--e:0.0-->"`, `"<!--/e:0.0-->"`,
+		`"<!--t:0.1-->"`, `"<!--/t:0.1-->"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %s, want it to contain %s", got, want)
+		}
+	}
+}
+
+func TestHydrateOff(t *testing.T) {
+	var got = compileView(t, `<div><p if={ show }>{ name }</p></div>`, Options{})
+	if strings.Contains(got, "Hydrate") {
+		t.Errorf("got %s, want no hydration", got)
+	}
+}
@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"strings"
 	"unicode"
 
@@ -11,16 +13,18 @@ import (
 
 var verbose = (*utils.Verbose).New(nil)
 
-func runeCount(s string) int {
-	var count = 0
-	for range s {
-		count++
+// EOF is the character consumed once the source is exhausted.
+const EOF rune = -1
+
+// utf16Len is the number of UTF-16 code units encoding r, EOF counts as one
+// column.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
 	}
-	return count
+	return 1
 }
 
-const EOF = "EOF"
-
 // https://infra.spec.whatwg.org/#ascii-alpha
 func isAsciiAlpha(r rune) bool {
 	return unicode.IsLetter(r)
@@ -33,25 +37,20 @@ func isAsciiUpperAlpha(r rune) bool {
 
 // https://infra.spec.whatwg.org/#ascii-whitespace
 func isAsciiWhiteSpace(r rune) bool {
-	if string(r) == "\t" {
-		return true
-	}
-	if string(r) == "\n" {
+	if r == '\t' || r == '\n' {
 		return true
 	}
 	return unicode.IsSpace(r)
 }
 
-func intoArray(s string) ([]rune, []string) {
-	var aR = []rune{}
-	var aC = []string{}
-	for _, r := range s {
-		aR = append(aR, r)
-		aC = append(aC, string(r))
-	}
-	aR = append(aR, 0)
-	aC = append(aC, EOF)
-	return aR, aC
+var directiveNamespaces = map[string]tokens.AttributeType{
+	"on":    tokens.EventAttribute,
+	"class": tokens.DynamicAttribute,
+	"bind":  tokens.DynamicAttribute,
+	"style": tokens.DynamicAttribute,
+	"xlink": tokens.NormalAttribute,
+	"xml":   tokens.NormalAttribute,
+	"xmlns": tokens.NormalAttribute,
 }
 
 // https://html.spec.whatwg.org/#tokenization
@@ -94,78 +93,163 @@ const (
 
 type Lexer struct {
 	codeIndentCount       int
-	char                  string
-	chars                 []string
-	curser                int
+	column                int
+	err                   error
+	input                 *bufio.Reader
 	KeywordAttributeNames map[string]interface{}
-	length                int
 	lineNumber            int
-	lineNumberMap         map[int]int
-	peakBuffer            string
+	offset                int
+	previousColumn        int
+	previousUTF16Column   int
+	queue                 []tokens.Token
 	_rune                 rune
-	rubbishBuffer         string
-	runes                 []rune
+	readErr               error
+	reconsume             bool
+	rubbishBuffer         []rune
 	Source                string
 	state                 string
 	token                 tokens.Token
+	tokenErr              error
 	Tokens                []tokens.Token
+	utf16Column           int
+	width                 int
 }
 
 func New(source string) *Lexer {
-	var runes, chars = intoArray(source)
+	var lexer = NewReader(strings.NewReader(source))
+	lexer.Source = source
+	return lexer
+}
+
+// NewReader returns a Lexer reading its source from r as tokens are asked
+// for, Source is left empty.
+func NewReader(r io.Reader) *Lexer {
 	return &Lexer{
 		codeIndentCount:       0,
-		char:                  chars[0],
-		chars:                 chars,
-		curser:                0,
+		column:                0,
+		err:                   nil,
+		input:                 bufio.NewReader(r),
 		KeywordAttributeNames: make(map[string]interface{}),
-		length:                len(chars),
 		lineNumber:            1,
-		lineNumberMap:         make(map[int]int),
-		peakBuffer:            "",
-		rubbishBuffer:         "",
-		_rune:                 runes[0],
-		runes:                 runes,
+		offset:                0,
+		previousColumn:        0,
+		previousUTF16Column:   0,
+		queue:                 []tokens.Token{},
+		_rune:                 0,
+		readErr:               nil,
+		reconsume:             false,
+		rubbishBuffer:         []rune{},
 		state:                 dataState,
-		Source:                source,
+		Source:                "",
 		token:                 nil,
-		Tokens:                []tokens.Token{}}
+		Tokens:                []tokens.Token{},
+		utf16Column:           0,
+		width:                 0}
 }
 
 func (_self *Lexer) clearRubbishBuffer() {
-	_self.rubbishBuffer = ""
+	_self.rubbishBuffer = _self.rubbishBuffer[:0]
 }
 
-func (_self *Lexer) appendToRubbishBuffer(s string) {
-	_self.rubbishBuffer = _self.rubbishBuffer + s
+func (_self *Lexer) appendToRubbishBuffer(r rune) {
+	_self.rubbishBuffer = append(_self.rubbishBuffer, r)
 }
 
 func (_self *Lexer) flushRubbishBufferToToken() {
-	_self.token.AppendToData(_self.rubbishBuffer)
-	_self.rubbishBuffer = ""
+	for _, r := range _self.rubbishBuffer {
+		_self.check(_self.token.AppendToData(r))
+	}
+	_self.rubbishBuffer = _self.rubbishBuffer[:0]
+}
+
+func (_self *Lexer) appendToData(s string) {
+	for _, r := range s {
+		_self.check(_self.token.AppendToData(r))
+	}
 }
 
+// consume reads the next character, EOF once the input is exhausted or
+// cannot be read.
 func (_self *Lexer) consume() {
-	if _self.curser+1 >= _self.length {
-		_self.char = EOF
-		_self.lineNumber++
-	} else {
-		_self.char = _self.chars[_self.curser]
-		_self._rune = _self.runes[_self.curser]
-		_self.curser++
+	switch {
+	case _self.reconsume:
+		_self.reconsume = false
+	case _self._rune == EOF:
+		_self.newLine()
+	default:
+		_self.offset += _self.width
+		r, size, err := _self.input.ReadRune()
+		if err != nil {
+			if err != io.EOF {
+				_self.readErr = err
+			}
+			_self._rune = EOF
+			_self.width = 0
+			_self.newLine()
+		} else {
+			_self._rune = r
+			_self.width = size
+		}
 	}
-	_self.lineNumberMap[_self.lineNumber]++
-	if _self.char == "\n" {
-		_self.lineNumber++
+	_self.column++
+	_self.utf16Column += utf16Len(_self._rune)
+	if _self._rune == '\n' {
+		_self.newLine()
 	}
 }
 
+// newLine moves before the first column of the next line, the column left
+// is kept so that reconsuming a line feed can return to it.
+func (_self *Lexer) newLine() {
+	_self.lineNumber++
+	_self.previousColumn = _self.column
+	_self.previousUTF16Column = _self.utf16Column
+	_self.column = 0
+	_self.utf16Column = 0
+}
+
+// reConsume makes the next consume return the current character again.
 func (_self *Lexer) reConsume() {
-	if _self.char == "\n" {
+	if _self._rune == '\n' {
 		_self.lineNumber--
+		_self.column = _self.previousColumn
+		_self.utf16Column = _self.previousUTF16Column
+	}
+	_self.column--
+	_self.utf16Column -= utf16Len(_self._rune)
+	_self.reconsume = true
+}
+
+// position covers the current character.
+func (_self *Lexer) position() tokens.Position {
+	return tokens.Position{
+		StartLine:        _self.lineNumber,
+		StartColumn:      _self.column,
+		StartColumnUTF16: _self.utf16Column - utf16Len(_self._rune) + 1,
+		StartOffset:      _self.offset,
+		EndLine:          _self.lineNumber,
+		EndColumn:        _self.column,
+		EndColumnUTF16:   _self.utf16Column,
+		EndOffset:        _self.offset + _self.width,
 	}
-	_self.lineNumberMap[_self.lineNumber]--
-	_self.curser--
+}
+
+// positionBefore is the empty position just before the current character.
+func (_self *Lexer) positionBefore() tokens.Position {
+	var position = _self.position()
+	position.EndColumn = position.StartColumn - 1
+	position.EndColumnUTF16 = position.StartColumnUTF16 - 1
+	position.EndOffset = position.StartOffset
+	return position
+}
+
+// positionAfter is the empty position just after the current character.
+func (_self *Lexer) positionAfter() tokens.Position {
+	var position = _self.position()
+	position.StartColumn = position.EndColumn + 1
+	position.StartColumnUTF16 = position.EndColumnUTF16 + 1
+	position.StartOffset = position.EndOffset
+	return position
 }
 
 func (_self *Lexer) consumeN(n int) {
@@ -179,95 +263,219 @@ func (_self *Lexer) ignore() {
 	// Do nothing!
 }
 
-func (_self *Lexer) peak(n int) {
-	if _self.curser+n >= _self.length {
-		return
+// peak reports whether the source continues with s, without consuming it.
+func (_self *Lexer) peak(s string) bool {
+	var buffer, _ = _self.input.Peek(len(s))
+	return string(buffer) == s
+}
+
+// trace logs the character consumed in the current state, the check keeps
+// the arguments from being boxed for every character when not tracing.
+func (_self *Lexer) trace() {
+	if verbose.Level >= 6 {
+		verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self._rune)
 	}
-	_self.peakBuffer = ""
-	for i := _self.curser; i < _self.curser+n; i++ {
-		_self.peakBuffer = _self.peakBuffer + _self.chars[i]
+}
+
+func (_self *Lexer) normaliseTagName() {
+	var name = _self.token.GetName()
+	if normalised := normaliseTagName(name); normalised != name {
+		_self.check(_self.token.SetName(normalised))
 	}
 }
 
 func (_self *Lexer) emitToken() {
 	var position = _self.token.GetPosition()
-	position.EndLine = _self.lineNumber
-	position.EndColumn = _self.lineNumberMap[_self.lineNumber]
+	var end = _self.position()
+	position.EndLine = end.EndLine
+	position.EndColumn = end.EndColumn
+	position.EndColumnUTF16 = end.EndColumnUTF16
+	position.EndOffset = end.EndOffset
+	// Start and end tags and comments are created after their ASCII
+	// delimiters, `<`, `</` and `<`, have been consumed.
 	switch _self.token.GetType() {
 	case tokens.StartTag:
+		_self.normaliseTagName()
+		if !_self.token.GetIsComponent() && IsVoidElement(_self.token.GetName()) {
+			_self.check(_self.token.SetIsSelfClosing(true))
+		}
 		position.StartColumn--
+		position.StartColumnUTF16--
+		position.StartOffset--
 	case tokens.EndTag:
+		_self.normaliseTagName()
 		position.StartColumn -= 2
+		position.StartColumnUTF16 -= 2
+		position.StartOffset -= 2
 	case tokens.Comment:
 		position.StartColumn--
+		position.StartColumnUTF16--
+		position.StartOffset--
 	case tokens.Text:
 		position.EndLine = position.StartLine
-		position.EndColumn = position.StartColumn
+		position.EndColumn = position.StartColumn - 1
+		position.EndColumnUTF16 = position.StartColumnUTF16 - 1
+		position.EndOffset = position.StartOffset
 		for _, r := range _self.token.GetData() {
-			position.EndColumn++
-			if string(r) == "\n" {
-				position.EndLine++
-				position.EndColumn = 1
-			}
+			position.Extend(r)
 		}
-		position.EndColumn--
 	case tokens.Code:
 	case tokens.EndOfFile:
 		position.StartColumn--
+		position.StartColumnUTF16--
 		position.EndColumn--
+		position.EndColumnUTF16--
 	}
 	_self.token.SetPosition(position)
 	if verbose.Level >= 3 {
 		_self.token.Print()
 	}
-	_self.Tokens = append(_self.Tokens, _self.token)
+	_self.queue = append(_self.queue, _self.token)
 	_self.token = nil
 }
 
+// newAttribute starts an attribute at the current character, end tags
+// cannot have attributes.
+func (_self *Lexer) newAttribute() error {
+	if _self.token.GetType() == tokens.EndTag {
+		return fmt.Errorf("error in %s: end-tag-with-attributes", _self.state)
+	}
+	_self.check(_self.token.NewAttribute(_self.positionBefore()))
+	return nil
+}
+
+// classifyAttribute sets the type of the current attribute once its name is
+// complete. Registered keywords must match exactly and directives must be of
+// the form `namespace:name` where namespace is one of directiveNamespaces.
+// The xlink, xml and xmlns namespaces are plain (namespaced) attributes.
+// Event directives may carry modifiers, `on:click|once|preventDefault`.
+func (_self *Lexer) classifyAttribute() error {
+	var attributes = _self.token.GetAttributes()
+	var name = attributes[len(attributes)-1].Name
+	if _, ok := _self.KeywordAttributeNames[name]; ok {
+		_self.check(_self.token.SetAttributeType(tokens.KeywordAttribute))
+		return nil
+	}
+	namespace, local, found := strings.Cut(name, ":")
+	if !found {
+		if normalised := normaliseAttributeName(name); normalised != name && !_self.token.GetIsComponent() {
+			_self.check(_self.token.SetAttributeName(normalised))
+		}
+		return nil
+	}
+	attributeType, ok := directiveNamespaces[namespace]
+	if !ok || local == "" || strings.Contains(local, ":") ||
+		(attributeType == tokens.EventAttribute && strings.Contains("|"+local+"|", "||")) {
+		return fmt.Errorf("error in %s: invalid-directive-name %s", _self.state, name)
+	}
+	if attributeType == tokens.NormalAttribute {
+		return nil
+	}
+	_self.check(_self.token.SetAttributeType(attributeType))
+	return nil
+}
+
+// literalValue checks that the current attribute can have a quoted or an
+// unquoted value, the value of a directive is Go code, `on:click={fn}`.
+func (_self *Lexer) literalValue() error {
+	switch _self.token.GetAttributeType() {
+	case tokens.DynamicAttribute, tokens.EventAttribute:
+		var attributes = _self.token.GetAttributes()
+		return fmt.Errorf("error in %s: directive-value-not-code %s", _self.state, attributes[len(attributes)-1].Name)
+	}
+	return nil
+}
+
+// Tokenise fills Tokens with every token of the source, errors are
+// returned as a *tokens.Error at the position the lexer stopped.
 func (_self *Lexer) Tokenise() error {
 	verbose.Printf(4, "::: Lexer.Tokenise() :::\n")
 	verbose.Printf(4, "KeywordAttributeNames:\n%v\n", _self.KeywordAttributeNames)
-	for _self.state != endOfFileState {
+	for {
+		token, err := _self.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_self.Tokens = append(_self.Tokens, token)
+	}
+}
+
+// Next lexes the source up to the next token and returns it, io.EOF after
+// the EndOfFile token. Only the current token is held, so a large source
+// read from an io.Reader is never in memory as a whole. Errors are returned
+// as a *tokens.Error and every later call returns the same error.
+func (_self *Lexer) Next() (tokens.Token, error) {
+	if _self.err != nil {
+		return nil, _self.err
+	}
+	if len(_self.queue) == 0 && _self.state != endOfFileState {
+		err := _self.tokenise()
+		if err == nil {
+			err = _self.readErr
+		}
+		if err != nil {
+			_self.err = &tokens.Error{
+				Position: _self.position(),
+				Err:      err,
+			}
+			return nil, _self.err
+		}
+	}
+	if len(_self.queue) == 0 {
+		return nil, io.EOF
+	}
+	var token = _self.queue[0]
+	_self.queue = _self.queue[:copy(_self.queue, _self.queue[1:])]
+	return token, nil
+}
+
+// tokenise runs the state machine until it has emitted a token or reached
+// the end of the source.
+func (_self *Lexer) tokenise() error {
+	for _self.state != endOfFileState && len(_self.queue) == 0 && _self.tokenErr == nil {
 		switch _self.state {
 
 		case dataState: // https://html.spec.whatwg.org/#data-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.ignore()
 				continue
 			}
-			switch _self.char {
-			case "{":
-				_self.token = tokens.NewCodeToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+			switch _self._rune {
+			case '{':
+				_self.token = tokens.NewCodeToken(_self.position())
 				_self.codeIndentCount = 0
 				_self.state = beforeTextCodeState
-			case "<":
+			case '<':
 				_self.state = tagOpenState
 			case EOF:
-				_self.token = tokens.NewEndOfFileToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewEndOfFileToken(_self.position())
 				_self.emitToken()
 				_self.state = endOfFileState
 			default:
-				_self.token = tokens.NewTextToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewTextToken(_self.position())
 				_self.reConsume()
 				_self.state = textState
 			}
 
 		case textState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
-				_self.appendToRubbishBuffer(_self.char)
+				_self.appendToRubbishBuffer(_self._rune)
 				continue
 			}
-			switch _self.char {
-			case "{":
+			switch _self._rune {
+			case '{':
 				_self.clearRubbishBuffer()
 				_self.emitToken()
 				_self.reConsume()
 				_self.state = dataState
-			case "<":
+			case '<':
 				_self.clearRubbishBuffer()
 				_self.emitToken()
 				_self.reConsume()
@@ -275,224 +483,237 @@ func (_self *Lexer) Tokenise() error {
 			case EOF:
 				_self.clearRubbishBuffer()
 				_self.emitToken()
-				_self.token = tokens.NewEndOfFileToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewEndOfFileToken(_self.position())
 				_self.emitToken()
 				_self.state = endOfFileState
 			default:
 				_self.flushRubbishBufferToToken()
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 			}
 
 		case tagOpenState: // https://html.spec.whatwg.org/#tag-open-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiAlpha(_self._rune) {
-				_self.token = tokens.NewStartTagToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewStartTagToken(_self.position())
 				_self.reConsume()
 				_self.state = tagNameState
 				continue
 			}
-			switch _self.char {
-			case "!":
+			switch _self._rune {
+			case '!':
 				_self.state = markupDeclarationOpenState
-			case "/":
+			case '/':
 				_self.state = endTagOpenState
-			case "?":
-				verbose.Printf(0, "error in %s: unexpected-question-mark-instead-of-tag-name\n", _self.state)
+			case '?':
 				return fmt.Errorf("error in %s: unexpected-question-mark-instead-of-tag-name", _self.state)
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-before-tag-name\n", _self.state)
 				return fmt.Errorf("error in %s: eof-before-tag-name", _self.state)
 			default:
-				verbose.Printf(0, "error in %s: invalid-first-character-of-tag-name\n", _self.state)
 				return fmt.Errorf("error in %s: invalid-first-character-of-tag-name", _self.state)
 			}
 
 		case endTagOpenState: // https://html.spec.whatwg.org/#tag-open-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiAlpha(_self._rune) {
-				_self.token = tokens.NewEndTagToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewEndTagToken(_self.position())
 				_self.reConsume()
 				_self.state = tagNameState
 				continue
 			}
-			switch _self.char {
-			case ">":
-				verbose.Printf(0, "error in %s: missing-end-tag-name\n", _self.state)
+			switch _self._rune {
+			case '>':
 				return fmt.Errorf("error in %s: missing-end-tag-name", _self.state)
 
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-before-tag-name\n", _self.state)
 				return fmt.Errorf("error in %s: eof-before-tag-name", _self.state)
 
 			default:
-				verbose.Printf(0, "error in %s: invalid-first-character-of-tag-name\n", _self.state)
 				return fmt.Errorf("error in %s: invalid-first-character-of-tag-name", _self.state)
 			}
 
 		case tagNameState: // https://html.spec.whatwg.org/#tag-name-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.state = beforeAttributeNameState
 				continue
 			}
-			if isAsciiUpperAlpha(_self._rune) {
-				if runeCount(_self.token.GetName()) == 0 {
-					_self.token.SetIsComponent(true)
-					_self.token.AppendToName(_self.char)
-					continue
-				}
-				_self.token.AppendToName(strings.ToLower(_self.char))
-				continue
+			if isAsciiUpperAlpha(_self._rune) &&
+				_self.token.GetName() == "" &&
+				_self.token.GetType() == tokens.StartTag {
+				_self.check(_self.token.SetIsComponent(true))
 			}
-			switch _self.char {
-			case "/":
+			switch _self._rune {
+			case '/':
 				_self.state = selfClosingStartTagState
-			case ">":
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				_self.token.AppendToName(_self.char)
+				_self.check(_self.token.AppendToName(_self._rune))
 			}
 
 		case beforeAttributeNameState: // https://html.spec.whatwg.org/#before-attribute-name-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.ignore()
 				continue
 			}
-			switch _self.char {
-			case "/":
+			switch _self._rune {
+			case '/':
 				_self.reConsume()
 				_self.state = afterAttributeNameState
-			case ">":
+			case '>':
 				_self.reConsume()
 				_self.state = afterAttributeNameState
 			case EOF:
 				_self.reConsume()
 				_self.state = afterAttributeNameState
-			case "=":
-				verbose.Printf(0, "error in %s: unexpected-equals-sign-before-attribute-name\n", _self.state)
+			case '=':
 				return fmt.Errorf("error in %s: unexpected-equals-sign-before-attribute-name", _self.state)
+			case '{':
+				err := _self.newAttribute()
+				if err != nil {
+					return err
+				}
+				_self.check(_self.token.SetAttributeType(tokens.SpreadAttribute))
+				_self.check(_self.token.SetAttributeValuePosition(_self.positionAfter()))
+				_self.codeIndentCount = 0
+				_self.state = beforeAttributeValueCodeState
 			default:
-				_self.token.NewAttribute(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				err := _self.newAttribute()
+				if err != nil {
+					return err
+				}
 				_self.reConsume()
 				_self.state = attributeNameState
 			}
 
 		case attributeNameState: // https://html.spec.whatwg.org/#attribute-name-state
-			for mapK := range _self.KeywordAttributeNames {
-				_self.peak(runeCount(mapK))
-				if _self.peakBuffer == mapK {
-					_self.token.SetAttributeType(tokens.KeywordAttribute)
-				}
-			}
-			_self.peak(runeCount("on"))
-			if _self.peakBuffer == "on" {
-				_self.token.SetAttributeType(tokens.EventAttribute)
-			}
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
-				_self.token.SetAttributeType(tokens.ArgumentAttribute)
+				err := _self.classifyAttribute()
+				if err != nil {
+					return err
+				}
+				if _self.token.GetAttributeType() == tokens.NormalAttribute {
+					_self.check(_self.token.SetAttributeType(tokens.ArgumentAttribute))
+				}
 				_self.reConsume()
 				_self.state = afterAttributeNameState
 				continue
 			}
-			if isAsciiUpperAlpha(_self._rune) {
-				_self.token.AppendToAttributeName(strings.ToLower(_self.char))
-				continue
-			}
-			switch _self.char {
-			case "/":
-				_self.reConsume()
-				_self.state = afterAttributeNameState
-			case ">":
-				_self.reConsume()
-				_self.state = afterAttributeNameState
-			case EOF:
+			switch _self._rune {
+			case '/', '>', EOF:
+				err := _self.classifyAttribute()
+				if err != nil {
+					return err
+				}
+				if _self.token.GetAttributeType() == tokens.NormalAttribute {
+					_self.check(_self.token.SetAttributeType(tokens.ArgumentAttribute))
+				}
 				_self.reConsume()
 				_self.state = afterAttributeNameState
-			case "=":
-				_self.state = beforeAttributeValueState
-			case `"`:
-				verbose.Printf(0, "error in %s: unexpected-character-in-attribute-name %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %s", _self.state, _self.char)
-			case "'":
-				verbose.Printf(0, "error in %s: unexpected-character-in-attribute-name %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %s", _self.state, _self.char)
-			case "<":
-				verbose.Printf(0, "error in %s: unexpected-character-in-attribute-name %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %s", _self.state, _self.char)
-			case ":":
-				if _self.token.GetAttributeType() != tokens.EventAttribute {
-					_self.token.SetAttributeType(tokens.DynamicAttribute)
+			case '=':
+				err := _self.classifyAttribute()
+				if err != nil {
+					return err
 				}
-				_self.token.AppendToAttributeName(_self.char)
+				_self.state = beforeAttributeValueState
+			case '"':
+				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %c", _self.state, _self._rune)
+			case '\'':
+				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %c", _self.state, _self._rune)
+			case '<':
+				return fmt.Errorf("error in %s: unexpected-character-in-attribute-name %c", _self.state, _self._rune)
 			default:
-				_self.token.AppendToAttributeName(_self.char)
+				_self.check(_self.token.AppendToAttributeName(_self._rune))
 			}
 
 		case afterAttributeNameState: // https://html.spec.whatwg.org/#after-attribute-name-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.ignore()
 				continue
 			}
-			switch _self.char {
-			case "/":
+			switch _self._rune {
+			case '/':
 				_self.state = selfClosingStartTagState
-			case "=":
+			case '=':
 				_self.state = beforeAttributeValueState
-			case ">":
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
+			case '{':
+				_self.reConsume()
+				_self.state = beforeAttributeNameState
 			default:
-				_self.token.NewAttribute(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				err := _self.newAttribute()
+				if err != nil {
+					return err
+				}
 				_self.reConsume()
 				_self.state = attributeNameState
 			}
 
 		case beforeAttributeValueState: // https://html.spec.whatwg.org/#before-attribute-value-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.ignore()
 				continue
 			}
-			switch _self.char {
-			case "{":
+			switch _self._rune {
+			case '{':
+				switch _self.token.GetAttributeType() {
+				case tokens.NormalAttribute, tokens.ArgumentAttribute:
+					_self.check(_self.token.SetAttributeType(tokens.ExpressionAttribute))
+				}
+				_self.check(_self.token.SetAttributeValuePosition(_self.positionAfter()))
 				_self.codeIndentCount = 0
 				_self.state = beforeAttributeValueCodeState
-			case `"`:
+			case '"':
+				err := _self.literalValue()
+				if err != nil {
+					return err
+				}
+				_self.check(_self.token.SetAttributeValuePosition(_self.positionAfter()))
 				_self.state = attributeValueDoubleQuotedState
-			case "'":
+			case '\'':
+				err := _self.literalValue()
+				if err != nil {
+					return err
+				}
+				_self.check(_self.token.SetAttributeValuePosition(_self.positionAfter()))
 				_self.state = attributeValueSingleQuotedState
-			case ">":
-				verbose.Printf(0, "error in %s: missing-attribute-value\n", _self.state)
+			case '>':
 				return fmt.Errorf("error in %s: missing-attribute-value", _self.state)
 			default:
+				err := _self.literalValue()
+				if err != nil {
+					return err
+				}
+				_self.check(_self.token.SetAttributeValuePosition(_self.positionBefore()))
 				_self.reConsume()
 				_self.state = attributeValueUnquotedState
 			}
 
 		case beforeTextCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "{":
+			_self.trace()
+			switch _self._rune {
+			case '{':
 				_self.codeIndentCount++
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 				_self.state = textCodeState
 			default:
 				_self.reConsume()
@@ -501,11 +722,11 @@ func (_self *Lexer) Tokenise() error {
 
 		case beforeAttributeValueCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "{":
+			_self.trace()
+			switch _self._rune {
+			case '{':
 				_self.codeIndentCount++
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 				_self.state = attributeValueCodeState
 			default:
 				_self.reConsume()
@@ -514,200 +735,192 @@ func (_self *Lexer) Tokenise() error {
 
 		case textCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "{":
+			_self.trace()
+			switch _self._rune {
+			case '{':
 				_self.reConsume()
 				_self.state = beforeTextCodeState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-code\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-code", _self.state)
-			case "}":
+			case '}':
 				_self.reConsume()
 				_self.state = afterTextCodeState
 			default:
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 			}
 
 		case attributeValueCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "{":
+			_self.trace()
+			switch _self._rune {
+			case '{':
 				_self.reConsume()
 				_self.state = beforeAttributeValueCodeState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-code\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-code", _self.state)
-			case "}":
+			case '}':
 				_self.reConsume()
 				_self.state = afterAttributeValueCodeState
 			default:
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 			}
 
 		case afterTextCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "}":
+			_self.trace()
+			switch _self._rune {
+			case '}':
 				if _self.codeIndentCount <= 0 {
 					_self.emitToken()
 					_self.state = dataState
 					continue
 				}
 				_self.codeIndentCount--
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 				_self.state = textCodeState
 			}
 
 		case afterAttributeValueCodeState: // NOT IN SPEC
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "}":
+			_self.trace()
+			switch _self._rune {
+			case '}':
 				if _self.codeIndentCount <= 0 {
+					if _self.token.GetAttributeType() == tokens.SpreadAttribute {
+						var attributes = _self.token.GetAttributes()
+						var value = attributes[len(attributes)-1].Value
+						if !strings.HasPrefix(strings.TrimSpace(value), "...") {
+							return fmt.Errorf("error in %s: invalid-spread-attribute {%s}", _self.state, value)
+						}
+					}
 					_self.state = afterAttributeValueQuotedState
 					continue
 				}
 				_self.codeIndentCount--
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 				_self.state = attributeValueCodeState
 			}
 
 		case attributeValueDoubleQuotedState: // https://html.spec.whatwg.org/#attribute-value-(double-quoted)-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case `"`:
+			_self.trace()
+			switch _self._rune {
+			case '"':
 				_self.state = afterAttributeValueQuotedState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 			}
 
 		case attributeValueSingleQuotedState: // https://html.spec.whatwg.org/#attribute-value-(single-quoted)-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "'":
+			_self.trace()
+			switch _self._rune {
+			case '\'':
 				_self.state = afterAttributeValueQuotedState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 			}
 
 		case attributeValueUnquotedState: // https://html.spec.whatwg.org/#attribute-value-(unquoted)-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.state = beforeAttributeNameState
 				continue
 			}
-			switch _self.char {
-			case ">":
+			switch _self._rune {
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
-			case `"`:
-				verbose.Printf(0, "error in %s: unexpected-character-in-unquoted-attribute-value %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %s", _self.state, _self.char)
-			case "'":
-				verbose.Printf(0, "error in %s: unexpected-character-in-unquoted-attribute-value %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %s", _self.state, _self.char)
-			case "<":
-				verbose.Printf(0, "error in %s: unexpected-character-in-unquoted-attribute-value %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %s", _self.state, _self.char)
-			case "=":
-				verbose.Printf(0, "error in %s: unexpected-character-in-unquoted-attribute-value %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %s", _self.state, _self.char)
-			case "`":
-				verbose.Printf(0, "error in %s: unexpected-character-in-unquoted-attribute-value %s\n", _self.state, _self.char)
-				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %s", _self.state, _self.char)
+			case '"':
+				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %c", _self.state, _self._rune)
+			case '\'':
+				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %c", _self.state, _self._rune)
+			case '<':
+				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %c", _self.state, _self._rune)
+			case '=':
+				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %c", _self.state, _self._rune)
+			case '`':
+				return fmt.Errorf("error in %s: unexpected-character-in-unquoted-attribute-value %c", _self.state, _self._rune)
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				_self.token.AppendToAttributeValue(_self.char)
+				_self.check(_self.token.AppendToAttributeValue(_self._rune))
 			}
 
 		case afterAttributeValueQuotedState: // https://html.spec.whatwg.org/#after-attribute-value-(quoted)-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
+			_self.trace()
 			if isAsciiWhiteSpace(_self._rune) {
 				_self.state = beforeAttributeNameState
 				continue
 			}
-			switch _self.char {
-			case "/":
+			switch _self._rune {
+			case '/':
 				_self.state = selfClosingStartTagState
-			case ">":
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				verbose.Printf(0, "error in %s: missing-whitespace-between-attributes\n", _self.state)
 				return fmt.Errorf("error in %s: missing-whitespace-between-attributes", _self.state)
 			}
 
 		case selfClosingStartTagState: // https://html.spec.whatwg.org/#self-closing-start-tag-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case ">":
-				_self.token.SetIsSelfClosing(true)
+			_self.trace()
+			switch _self._rune {
+			case '>':
+				if _self.token.GetType() == tokens.EndTag {
+					return fmt.Errorf("error in %s: end-tag-with-trailing-solidus", _self.state)
+				}
+				_self.check(_self.token.SetIsSelfClosing(true))
 				_self.emitToken()
 				_self.state = dataState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-tag", _self.state)
 			default:
-				verbose.Printf(0, "error in %s: unexpected-solidus-in-tag\n", _self.state)
 				return fmt.Errorf("error in %s: unexpected-solidus-in-tag", _self.state)
 			}
 
 		case bogusCommentState: // https://html.spec.whatwg.org/#bogus-comment-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case ">":
+			_self.trace()
+			switch _self._rune {
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
 			case EOF:
 				_self.emitToken()
-				_self.token = tokens.NewEndOfFileToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
+				_self.token = tokens.NewEndOfFileToken(_self.position())
 				_self.emitToken()
 			default:
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 			}
 
 		case markupDeclarationOpenState: // https://html.spec.whatwg.org/#markup-declaration-open-state
-			var n = 2
-			_self.peak(n)
-			switch _self.peakBuffer {
-			case "--":
+			switch {
+			case _self.peak("--"):
 				verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, "--")
-				_self.token = tokens.NewCommentToken(_self.lineNumber, _self.lineNumberMap[_self.lineNumber])
-				_self.consumeN(n)
+				_self.token = tokens.NewCommentToken(_self.position())
+				_self.consumeN(2)
 				_self.state = commentStartState
 			default:
-				verbose.Printf(0, "error in %s: incorrectly-opened-comment\n", _self.state)
 				return fmt.Errorf("error in %s: incorrectly-opened-comment", _self.state)
 			}
 
 		case commentStartState: // https://html.spec.whatwg.org/#comment-start-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
+			_self.trace()
+			switch _self._rune {
+			case '-':
 				_self.state = commentStartDashState
-			case ">":
-				verbose.Printf(0, "error in %s: abrupt-closing-of-empty-comment\n", _self.state)
+			case '>':
 				return fmt.Errorf("error in %s: abrupt-closing-of-empty-comment", _self.state)
 			default:
 				_self.reConsume()
@@ -716,47 +929,44 @@ func (_self *Lexer) Tokenise() error {
 
 		case commentStartDashState: // https://html.spec.whatwg.org/#comment-start-dash-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
+			_self.trace()
+			switch _self._rune {
+			case '-':
 				_self.state = commentEndState
-			case ">":
-				verbose.Printf(0, "error in %s: abrupt-closing-of-empty-comment\n", _self.state)
+			case '>':
 				return fmt.Errorf("error in %s: abrupt-closing-of-empty-comment", _self.state)
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-comment\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-comment", _self.state)
 			default:
-				_self.token.AppendToData("-")
+				_self.check(_self.token.AppendToData('-'))
 				_self.reConsume()
 				_self.state = commentState
 			}
 
 		case commentState: // https://html.spec.whatwg.org/#comment-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "<":
-				_self.token.AppendToData(_self.char)
+			_self.trace()
+			switch _self._rune {
+			case '<':
+				_self.check(_self.token.AppendToData(_self._rune))
 				_self.state = commentLessThanSignState
-			case "-":
+			case '-':
 				_self.state = commentEndDashState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-comment\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-comment", _self.state)
 			default:
-				_self.token.AppendToData(_self.char)
+				_self.check(_self.token.AppendToData(_self._rune))
 			}
 
 		case commentLessThanSignState: // https://html.spec.whatwg.org/#comment-less-than-sign-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "!":
-				_self.token.AppendToData(_self.char)
+			_self.trace()
+			switch _self._rune {
+			case '!':
+				_self.check(_self.token.AppendToData(_self._rune))
 				_self.state = commentLessThanSignBangState
-			case "<":
-				_self.token.AppendToData(_self.char)
+			case '<':
+				_self.check(_self.token.AppendToData(_self._rune))
 			default:
 				_self.reConsume()
 				_self.state = commentState
@@ -764,9 +974,9 @@ func (_self *Lexer) Tokenise() error {
 
 		case commentLessThanSignBangState: // https://html.spec.whatwg.org/#comment-less-than-sign-bang-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
+			_self.trace()
+			switch _self._rune {
+			case '-':
 				_self.state = commentLessThanSignBangDashState
 			default:
 				_self.reConsume()
@@ -775,9 +985,9 @@ func (_self *Lexer) Tokenise() error {
 
 		case commentLessThanSignBangDashState: // https://html.spec.whatwg.org/#comment-less-than-sign-bang-dash-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
+			_self.trace()
+			switch _self._rune {
+			case '-':
 				_self.state = commentLessThanSignBangDashDashState
 			default:
 				_self.reConsume()
@@ -786,73 +996,76 @@ func (_self *Lexer) Tokenise() error {
 
 		case commentLessThanSignBangDashDashState: // https://html.spec.whatwg.org/#comment-less-than-sign-bang-dash-dash-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case ">":
+			_self.trace()
+			switch _self._rune {
+			case '>':
 				_self.reConsume()
 				_self.state = commentState
 			case EOF:
 				_self.reConsume()
 				_self.state = commentState
 			default:
-				verbose.Printf(0, "error in %s: nested-comment\n", _self.state)
 				return fmt.Errorf("error in %s: nested-comment", _self.state)
 			}
 
 		case commentEndDashState: // https://html.spec.whatwg.org/#comment-end-dash-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
+			_self.trace()
+			switch _self._rune {
+			case '-':
 				_self.state = commentEndState
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-comment\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-comment", _self.state)
 			default:
-				_self.token.AppendToData("-")
+				_self.check(_self.token.AppendToData('-'))
 				_self.reConsume()
 				_self.state = commentState
 			}
 
 		case commentEndState: // https://html.spec.whatwg.org/#comment-end-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case ">":
+			_self.trace()
+			switch _self._rune {
+			case '>':
 				_self.emitToken()
 				_self.state = dataState
-			case "!":
+			case '!':
 				_self.state = commentEndBangState
-			case "-":
-				_self.token.AppendToData("-")
+			case '-':
+				_self.check(_self.token.AppendToData('-'))
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-comment\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-comment", _self.state)
 			default:
-				_self.token.AppendToData("-")
+				_self.check(_self.token.AppendToData('-'))
 				_self.reConsume()
 				_self.state = commentState
 			}
 
 		case commentEndBangState: // https://html.spec.whatwg.org/#comment-end-bang-state
 			_self.consume()
-			verbose.Printf(6, "~ in %s consuming: %q\n", _self.state, _self.char)
-			switch _self.char {
-			case "-":
-				_self.token.AppendToData("--!")
+			_self.trace()
+			switch _self._rune {
+			case '-':
+				_self.appendToData("--!")
 				_self.state = commentEndDashState
-			case ">":
-				verbose.Printf(0, "error in %s: incorrectly-closed-comment\n", _self.state)
+			case '>':
 				return fmt.Errorf("error in %s: incorrectly-closed-comment", _self.state)
 			case EOF:
-				verbose.Printf(0, "error in %s: eof-in-comment\n", _self.state)
 				return fmt.Errorf("error in %s: eof-in-comment", _self.state)
 			default:
-				_self.token.AppendToData("--!")
+				_self.appendToData("--!")
 				_self.reConsume()
 				_self.state = commentState
 			}
 		}
 	}
-	return nil
+	return _self.tokenErr
+}
+
+// check records the first error returned while building the current token,
+// which stops tokenise.
+func (_self *Lexer) check(err error) {
+	if _self.tokenErr == nil {
+		_self.tokenErr = err
+	}
 }
@@ -0,0 +1,352 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+const benchmarkView = `
+	<li class="item" class:done={ todo.Done } on:click={ toggle }>
+		<input type="checkbox" bind:checked={ todo.Done } />
+		<!-- the label is editable -->
+		<span title='title'>{ todo.Title } left</span>
+		<Button label="remove" { ...props } />
+	</li>`
+
+// benchmarkTemplate returns a view of n list items, about 250 bytes each.
+func benchmarkTemplate(n int) string {
+	return "<ul>" + strings.Repeat(benchmarkView, n) + "\n</ul>\n"
+}
+
+// BenchmarkTokenise lexes ever larger templates, the time and allocations
+// per byte stay the same as the template grows.
+func BenchmarkTokenise(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 10000} {
+		var source = benchmarkTemplate(n)
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var lexer = New(source)
+				lexer.KeywordAttributeNames["if"] = nil
+				err := lexer.Tokenise()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTokeniseLongText lexes a single text token and a single attribute
+// value of growing length, appending to them must not copy what was read.
+func BenchmarkTokeniseLongText(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		var text = strings.Repeat("word ", n)
+		var source = "<p title=\"" + text + "\">" + text + "</p>"
+		b.Run(fmt.Sprintf("words=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := New(source).Tokenise()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+var fuzzSeeds = []string{
+	"<div></div>",
+	`<div id="a" title='b' lang=en hidden>text { code } more</div>`,
+	`<ul each={ items } key={ item.ID }><li class:done={ d } on:click|once={ f }>{ item }</li></ul>`,
+	`<Button label="ok" { ...props } if={ show } />`,
+	"<!-- comment --><p>\n\té😀\n</p>",
+	`<svg viewBox="0 0 1 1"><circle r="1"/></svg>`,
+	"<div><",
+	"</div x>",
+	"<!x>",
+}
+
+// FuzzTokenise checks that the lexer returns an error rather than panicking,
+// that streaming the source one byte at a time lexes the same tokens and
+// error, and that the positions of valid UTF-8 sources are within the source.
+func FuzzTokenise(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		var lexer = New(source)
+		lexer.KeywordAttributeNames["if"] = nil
+		lexer.KeywordAttributeNames["each"] = nil
+		lexer.KeywordAttributeNames["key"] = nil
+		err := lexer.Tokenise()
+		var stream = NewReader(iotest.OneByteReader(strings.NewReader(source)))
+		stream.KeywordAttributeNames["if"] = nil
+		stream.KeywordAttributeNames["each"] = nil
+		stream.KeywordAttributeNames["key"] = nil
+		for i := 0; ; i++ {
+			token, streamErr := stream.Next()
+			if streamErr == io.EOF {
+				if err != nil || i != len(lexer.Tokens) {
+					t.Fatalf("streamed %d tokens and no error, want %d tokens and %v", i, len(lexer.Tokens), err)
+				}
+				break
+			}
+			if streamErr != nil {
+				if err == nil || streamErr.Error() != err.Error() || i != len(lexer.Tokens) {
+					t.Fatalf("streamed %d tokens and %v, want %d tokens and %v", i, streamErr, len(lexer.Tokens), err)
+				}
+				break
+			}
+			if i >= len(lexer.Tokens) {
+				t.Fatalf("streamed more than %d tokens", len(lexer.Tokens))
+			}
+			if got, want := describe(token), describe(lexer.Tokens[i]); got != want {
+				t.Fatalf("streamed token %d is\n%s\nwant\n%s", i, got, want)
+			}
+		}
+		if err != nil || !utf8.ValidString(source) {
+			return
+		}
+		for _, token := range lexer.Tokens {
+			var positions = []tokens.Position{token.GetPosition()}
+			if token.GetType() == tokens.StartTag {
+				for _, attribute := range token.GetAttributes() {
+					positions = append(positions, attribute.NamePosition, attribute.ValuePosition)
+				}
+			}
+			for _, position := range positions {
+				if position.StartOffset < 0 || position.StartOffset > position.EndOffset || position.EndOffset > len(source) {
+					t.Fatalf("%s token at %+v is outside the source", token.GetType(), position)
+				}
+			}
+		}
+	})
+}
+
+// startTag lexes source, with keywords registered, and returns its first
+// start tag.
+func startTag(t *testing.T, source string, keywords ...string) tokens.Token {
+	t.Helper()
+	var lexer = New(source)
+	for _, keyword := range keywords {
+		lexer.KeywordAttributeNames[keyword] = nil
+	}
+	err := lexer.Tokenise()
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	for _, token := range lexer.Tokens {
+		if token.GetType() == tokens.StartTag {
+			return token
+		}
+	}
+	t.Fatalf("%s has no start tag", source)
+	return nil
+}
+
+func TestAttributeTypes(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   []tokens.AttributeType
+	}{
+		{`<div one="1" open onboarding-step="2">`,
+			[]tokens.AttributeType{tokens.NormalAttribute, tokens.ArgumentAttribute, tokens.NormalAttribute}},
+		{`<div keyboard="k" iffy={ x } if={ show } key={ k }>`,
+			[]tokens.AttributeType{tokens.NormalAttribute, tokens.ExpressionAttribute, tokens.KeywordAttribute, tokens.KeywordAttribute}},
+		{`<div on:click={ f } on:keydown|enter|once={ g }>`,
+			[]tokens.AttributeType{tokens.EventAttribute, tokens.EventAttribute}},
+		{`<div class:dark={ d } style:color={ c } bind:value={ v }>`,
+			[]tokens.AttributeType{tokens.DynamicAttribute, tokens.DynamicAttribute, tokens.DynamicAttribute}},
+		{`<svg xmlns:xlink="x" xml:lang="en"><use xlink:href="#a"/></svg>`,
+			[]tokens.AttributeType{tokens.NormalAttribute, tokens.NormalAttribute}},
+	}
+	for _, test := range tests {
+		var attributes = startTag(t, test.source, "if", "key").GetAttributes()
+		if len(attributes) != len(test.want) {
+			t.Fatalf("%s: got %d attributes, want %d", test.source, len(attributes), len(test.want))
+		}
+		for i, attribute := range attributes {
+			if attribute.Type != test.want[i] {
+				t.Errorf("%s: %s is a %s, want %s", test.source, attribute.Name, attribute.Type, test.want[i])
+			}
+		}
+	}
+}
+
+func TestInvalidDirectives(t *testing.T) {
+	for _, source := range []string{
+		`<div on:={ f }>`,
+		`<div on:click||once={ f }>`,
+		`<div foo:bar="x">`,
+		`<div class:a:b={ c }>`,
+	} {
+		var err = New(source).Tokenise()
+		if err == nil || !strings.Contains(err.Error(), "invalid-directive-name") {
+			t.Errorf("%s: got %v, want invalid-directive-name", source, err)
+		}
+	}
+}
+
+func TestNameCase(t *testing.T) {
+	var tests = []struct {
+		source     string
+		name       string
+		attributes []string
+	}{
+		{`<dIV cLASS="a" ID="b" dataFoo="c">`, "div", []string{"class", "id", "dataFoo"}},
+		{`<UserCard userName="a" />`, "UserCard", []string{"userName"}},
+		{`<svg viewBox="0 0 1 1" preserveAspectRatio="none">`, "svg", []string{"viewBox", "preserveAspectRatio"}},
+		{`<foreignObject requiredExtensions="x">`, "foreignObject", []string{"requiredExtensions"}},
+		{`<my-Element aria-Label="a">`, "my-Element", []string{"aria-Label"}},
+	}
+	for _, test := range tests {
+		var token = startTag(t, test.source)
+		if token.GetName() != test.name {
+			t.Errorf("%s: got <%s>, want <%s>", test.source, token.GetName(), test.name)
+		}
+		for i, attribute := range token.GetAttributes() {
+			if attribute.Name != test.attributes[i] {
+				t.Errorf("%s: got %s, want %s", test.source, attribute.Name, test.attributes[i])
+			}
+		}
+	}
+}
+
+// describe prints what token holds through the getters of its type.
+func describe(token tokens.Token) string {
+	var result = fmt.Sprintf("%s %+v", token.GetType(), token.GetPosition())
+	switch token.GetType() {
+	case tokens.StartTag:
+		result = result + fmt.Sprintf(" %s %+v %t %t",
+			token.GetName(), token.GetAttributes(), token.GetIsComponent(), token.GetIsSelfClosing())
+	case tokens.EndTag:
+		result = result + " " + token.GetName()
+	case tokens.Comment, tokens.Text, tokens.Code:
+		result = result + " " + token.GetData()
+	}
+	return result
+}
+
+// TestNext checks that streaming a source one byte at a time yields the
+// tokens Tokenise collects, in the same order.
+func TestNext(t *testing.T) {
+	for _, source := range append(append([]string{}, fuzzSeeds[:6]...), benchmarkTemplate(3)) {
+		var lexer = New(source)
+		lexer.KeywordAttributeNames["if"] = nil
+		err := lexer.Tokenise()
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+		var stream = NewReader(iotest.OneByteReader(strings.NewReader(source)))
+		stream.KeywordAttributeNames["if"] = nil
+		for i := 0; ; i++ {
+			token, err := stream.Next()
+			if err == io.EOF {
+				if i != len(lexer.Tokens) {
+					t.Errorf("%s: got %d tokens, want %d", source, i, len(lexer.Tokens))
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", source, err)
+			}
+			if i >= len(lexer.Tokens) {
+				t.Fatalf("%s: got more than %d tokens", source, len(lexer.Tokens))
+			}
+			if got, want := describe(token), describe(lexer.Tokens[i]); got != want {
+				t.Errorf("%s: token %d is\n%s\nwant\n%s", source, i, got, want)
+			}
+		}
+		if _, err := stream.Next(); err != io.EOF {
+			t.Errorf("%s: got %v after the end, want io.EOF", source, err)
+		}
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (_self *countingReader) Read(p []byte) (int, error) {
+	var n, err = _self.r.Read(p)
+	_self.n += n
+	return n, err
+}
+
+// TestNextEarlyStop checks that a caller stopping after a few tokens has
+// not made the lexer read the whole source.
+func TestNextEarlyStop(t *testing.T) {
+	var source = benchmarkTemplate(1000)
+	var reader = &countingReader{r: strings.NewReader(source)}
+	var lexer = NewReader(reader)
+	for i := 0; i < 10; i++ {
+		_, err := lexer.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reader.n > 16*1024 {
+		t.Errorf("read %d of %d bytes for 10 tokens", reader.n, len(source))
+	}
+}
+
+func TestNextError(t *testing.T) {
+	var lexer = New("<p>a</p></div x>")
+	for _, want := range []tokens.TokenType{tokens.StartTag, tokens.Text, tokens.EndTag} {
+		token, err := lexer.Next()
+		if err != nil || token.GetType() != want {
+			t.Fatalf("got %v %v, want a %s token", token, err, want)
+		}
+	}
+	_, err := lexer.Next()
+	var positioned *tokens.Error
+	if !errors.As(err, &positioned) || !strings.Contains(err.Error(), "end-tag-with-attributes") {
+		t.Fatalf("got %v, want end-tag-with-attributes", err)
+	}
+	if positioned.Position.StartLine != 1 {
+		t.Errorf("got the error at %+v, want line 1", positioned.Position)
+	}
+	_, again := lexer.Next()
+	if again != err {
+		t.Errorf("got %v after the error, want the same error", again)
+	}
+}
+
+func TestNextReadError(t *testing.T) {
+	var failure = errors.New("disk on fire")
+	var lexer = NewReader(io.MultiReader(strings.NewReader("<p>text"), iotest.ErrReader(failure)))
+	var err error
+	for err == nil {
+		_, err = lexer.Next()
+	}
+	var positioned *tokens.Error
+	if !errors.Is(err, failure) || !errors.As(err, &positioned) {
+		t.Errorf("got %v, want the read error with its position", err)
+	}
+}
+
+func TestDirectiveValueNotCode(t *testing.T) {
+	for _, source := range []string{
+		`<div on:click="f">`,
+		`<div class:dark='d'>`,
+		`<div style:color=red>`,
+		`<input bind:value="v" />`,
+	} {
+		var err = New(source).Tokenise()
+		if err == nil || !strings.Contains(err.Error(), "directive-value-not-code") {
+			t.Errorf("%s: got %v, want directive-value-not-code", source, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// https://html.spec.whatwg.org/#elements-3
+var htmlElementNames = map[string]interface{}{
+	"a": nil, "abbr": nil, "address": nil, "area": nil, "article": nil,
+	"aside": nil, "audio": nil, "b": nil, "base": nil, "bdi": nil,
+	"bdo": nil, "blockquote": nil, "body": nil, "br": nil, "button": nil,
+	"canvas": nil, "caption": nil, "cite": nil, "code": nil, "col": nil,
+	"colgroup": nil, "data": nil, "datalist": nil, "dd": nil, "del": nil,
+	"details": nil, "dfn": nil, "dialog": nil, "div": nil, "dl": nil,
+	"dt": nil, "em": nil, "embed": nil, "fieldset": nil, "figcaption": nil,
+	"figure": nil, "footer": nil, "form": nil, "h1": nil, "h2": nil,
+	"h3": nil, "h4": nil, "h5": nil, "h6": nil, "head": nil,
+	"header": nil, "hgroup": nil, "hr": nil, "html": nil, "i": nil,
+	"iframe": nil, "img": nil, "input": nil, "ins": nil, "kbd": nil,
+	"label": nil, "legend": nil, "li": nil, "link": nil, "main": nil,
+	"map": nil, "mark": nil, "menu": nil, "meta": nil, "meter": nil,
+	"nav": nil, "noscript": nil, "object": nil, "ol": nil, "optgroup": nil,
+	"option": nil, "output": nil, "p": nil, "picture": nil, "pre": nil,
+	"progress": nil, "q": nil, "rp": nil, "rt": nil, "ruby": nil,
+	"s": nil, "samp": nil, "script": nil, "search": nil, "section": nil,
+	"select": nil, "slot": nil, "small": nil, "source": nil, "span": nil,
+	"strong": nil, "style": nil, "sub": nil, "summary": nil, "sup": nil,
+	"table": nil, "tbody": nil, "td": nil, "template": nil, "textarea": nil,
+	"tfoot": nil, "th": nil, "thead": nil, "time": nil, "title": nil,
+	"tr": nil, "track": nil, "u": nil, "ul": nil, "var": nil,
+	"video": nil, "wbr": nil, "svg": nil, "math": nil,
+}
+
+// https://html.spec.whatwg.org/#attributes-3
+var htmlAttributeNames = map[string]interface{}{
+	"accept": nil, "accept-charset": nil, "accesskey": nil, "action": nil,
+	"allow": nil, "alt": nil, "async": nil, "autocapitalize": nil,
+	"autocomplete": nil, "autofocus": nil, "autoplay": nil, "charset": nil,
+	"checked": nil, "cite": nil, "class": nil, "cols": nil,
+	"colspan": nil, "content": nil, "contenteditable": nil, "controls": nil,
+	"coords": nil, "crossorigin": nil, "data": nil, "datetime": nil,
+	"decoding": nil, "default": nil, "defer": nil, "dir": nil,
+	"dirname": nil, "disabled": nil, "download": nil, "draggable": nil,
+	"enctype": nil, "enterkeyhint": nil, "for": nil, "form": nil,
+	"formaction": nil, "formenctype": nil, "formmethod": nil, "formnovalidate": nil,
+	"formtarget": nil, "headers": nil, "height": nil, "hidden": nil,
+	"high": nil, "href": nil, "hreflang": nil, "http-equiv": nil,
+	"id": nil, "inert": nil, "inputmode": nil, "integrity": nil,
+	"is": nil, "ismap": nil, "itemid": nil, "itemprop": nil,
+	"itemref": nil, "itemscope": nil, "itemtype": nil, "kind": nil,
+	"label": nil, "lang": nil, "list": nil, "loading": nil,
+	"loop": nil, "low": nil, "max": nil, "maxlength": nil,
+	"media": nil, "method": nil, "min": nil, "minlength": nil,
+	"multiple": nil, "muted": nil, "name": nil, "nomodule": nil,
+	"nonce": nil, "novalidate": nil, "open": nil, "optimum": nil,
+	"pattern": nil, "ping": nil, "placeholder": nil, "playsinline": nil,
+	"popover": nil, "poster": nil, "preload": nil, "readonly": nil,
+	"referrerpolicy": nil, "rel": nil, "required": nil, "reversed": nil,
+	"role": nil, "rows": nil, "rowspan": nil, "sandbox": nil,
+	"scope": nil, "selected": nil, "shape": nil, "size": nil,
+	"sizes": nil, "slot": nil, "span": nil, "spellcheck": nil,
+	"src": nil, "srcdoc": nil, "srclang": nil, "srcset": nil,
+	"start": nil, "step": nil, "style": nil, "tabindex": nil,
+	"target": nil, "title": nil, "translate": nil, "type": nil,
+	"usemap": nil, "value": nil, "width": nil, "wrap": nil,
+}
+
+// https://html.spec.whatwg.org/#void-elements
+var voidElementNames = map[string]interface{}{
+	"area": nil, "base": nil, "br": nil, "col": nil, "embed": nil,
+	"hr": nil, "img": nil, "input": nil, "link": nil, "meta": nil,
+	"source": nil, "track": nil, "wbr": nil,
+}
+
+// IsVoidElement reports whether name is an HTML element without content,
+// `<br>` needs no end tag and is self-closing.
+func IsVoidElement(name string) bool {
+	var _, ok = voidElementNames[name]
+	return ok
+}
+
+func isComponentName(name string) bool {
+	var r, _ = utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// Component names keep their case, known HTML element names are lowercased
+// and everything else (SVG, MathML and custom elements) is left untouched.
+func normaliseTagName(name string) string {
+	if isComponentName(name) {
+		return name
+	}
+	var lower = strings.ToLower(name)
+	if _, ok := htmlElementNames[lower]; ok {
+		return lower
+	}
+	return name
+}
+
+// Known HTML attribute names are lowercased, everything else (viewBox,
+// preserveAspectRatio, component props) is left untouched.
+func normaliseAttributeName(name string) string {
+	var lower = strings.ToLower(name)
+	if _, ok := htmlAttributeNames[lower]; ok {
+		return lower
+	}
+	return name
+}
@@ -0,0 +1,17 @@
+package tokens
+
+import "fmt"
+
+// Error is an error found at a position in the source.
+type Error struct {
+	Position Position
+	Err      error
+}
+
+func (_self *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", _self.Position.StartLine, _self.Position.StartColumn, _self.Err)
+}
+
+func (_self *Error) Unwrap() error {
+	return _self.Err
+}
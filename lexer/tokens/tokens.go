@@ -1,6 +1,11 @@
 package tokens
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/goptos/utils"
 )
 
@@ -14,6 +19,23 @@ func runeCount(s string) int {
 	return count
 }
 
+// errNoAttribute is returned when an attribute is modified before
+// NewAttribute has started one.
+var errNoAttribute = errors.New("no attribute has been started")
+
+// noProperty reports a mutation of a property the token type does not have.
+func noProperty(tokenType TokenType, property string) error {
+	return fmt.Errorf("%s token has no %s property", tokenType, property)
+}
+
+// utf16Len is the number of UTF-16 code units encoding r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
 type TokenType string
 
 const (
@@ -25,21 +47,45 @@ const (
 	EndOfFile TokenType = "EndOfFile"
 )
 
+// Position locates a token or an attribute in the source. Lines and columns
+// are 1-based with an inclusive end, columns count runes and the UTF-16
+// columns count UTF-16 code units as the Language Server Protocol does.
+// Offsets are byte offsets with an exclusive end, the original text is
+// source[StartOffset:EndOffset].
 type Position struct {
-	StartLine   int
-	StartColumn int
-	EndLine     int
-	EndColumn   int
+	StartLine        int
+	StartColumn      int
+	StartColumnUTF16 int
+	StartOffset      int
+	EndLine          int
+	EndColumn        int
+	EndColumnUTF16   int
+	EndOffset        int
+}
+
+// Extend moves the end of the position over r.
+func (_self *Position) Extend(r rune) {
+	_self.EndOffset += utf8.RuneLen(r)
+	if r == '\n' {
+		_self.EndLine++
+		_self.EndColumn = 0
+		_self.EndColumnUTF16 = 0
+		return
+	}
+	_self.EndColumn++
+	_self.EndColumnUTF16 += utf16Len(r)
 }
 
 type AttributeType string
 
 const (
-	NormalAttribute   AttributeType = "NormalAttribute"
-	EventAttribute    AttributeType = "EventAttribute"
-	DynamicAttribute  AttributeType = "DynamicAttribute"
-	KeywordAttribute  AttributeType = "KeywordAttribute"
-	ArgumentAttribute AttributeType = "ArgumentAttribute"
+	NormalAttribute     AttributeType = "NormalAttribute"
+	EventAttribute      AttributeType = "EventAttribute"
+	DynamicAttribute    AttributeType = "DynamicAttribute"
+	KeywordAttribute    AttributeType = "KeywordAttribute"
+	ArgumentAttribute   AttributeType = "ArgumentAttribute"
+	ExpressionAttribute AttributeType = "ExpressionAttribute"
+	SpreadAttribute     AttributeType = "SpreadAttribute"
 )
 
 type Attribute struct {
@@ -51,36 +97,40 @@ type Attribute struct {
 }
 
 type StartTagToken struct {
-	_type         TokenType
-	position      Position
-	name          string
-	attributes    []Attribute
-	isComponent   bool
-	isSelfClosing bool
+	_type      TokenType
+	position   Position
+	name       strings.Builder
+	attributes []Attribute
+	// attributeName and attributeValue build the last attribute's Name and
+	// Value, each append is amortised O(1) rather than a copy of the string.
+	attributeName  strings.Builder
+	attributeValue strings.Builder
+	isComponent    bool
+	isSelfClosing  bool
 }
 
 type EndTagToken struct {
 	_type    TokenType
 	position Position
-	name     string
+	name     strings.Builder
 }
 
 type CommentToken struct {
 	_type    TokenType
 	position Position
-	data     string
+	data     strings.Builder
 }
 
 type TextToken struct {
 	_type    TokenType
 	position Position
-	data     string
+	data     strings.Builder
 }
 
 type CodeToken struct {
 	_type    TokenType
 	position Position
-	data     string
+	data     strings.Builder
 }
 
 type EndOfFileToken struct {
@@ -88,72 +138,43 @@ type EndOfFileToken struct {
 	position Position
 }
 
-func NewStartTagToken(ln int, ch int) *StartTagToken {
+func NewStartTagToken(position Position) *StartTagToken {
 	return &StartTagToken{
-		_type: StartTag,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch},
-		name:          "",
+		_type:         StartTag,
+		position:      position,
 		attributes:    []Attribute{},
 		isComponent:   false,
 		isSelfClosing: false}
 }
 
-func NewEndTagToken(ln int, ch int) *EndTagToken {
+func NewEndTagToken(position Position) *EndTagToken {
 	return &EndTagToken{
-		_type: EndTag,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch},
-		name: ""}
+		_type:    EndTag,
+		position: position}
 }
 
-func NewCommentToken(ln int, ch int) *CommentToken {
+func NewCommentToken(position Position) *CommentToken {
 	return &CommentToken{
-		_type: Comment,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch},
-		data: ""}
+		_type:    Comment,
+		position: position}
 }
 
-func NewTextToken(ln int, ch int) *TextToken {
+func NewTextToken(position Position) *TextToken {
 	return &TextToken{
-		_type: Text,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch},
-		data: ""}
+		_type:    Text,
+		position: position}
 }
 
-func NewCodeToken(ln int, ch int) *CodeToken {
+func NewCodeToken(position Position) *CodeToken {
 	return &CodeToken{
-		_type: Code,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch},
-		data: ""}
+		_type:    Code,
+		position: position}
 }
 
-func NewEndOfFileToken(ln int, ch int) *EndOfFileToken {
+func NewEndOfFileToken(position Position) *EndOfFileToken {
 	return &EndOfFileToken{
-		_type: EndOfFile,
-		position: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch}}
+		_type:    EndOfFile,
+		position: position}
 }
 
 type Token interface {
@@ -166,16 +187,18 @@ type Token interface {
 	GetIsSelfClosing() bool
 	GetIsComponent() bool
 	SetPosition(Position)
-	NewAttribute(int, int)
-	AppendToName(string)
-	AppendToData(string)
-	AppendToAttributeName(string)
-	AppendToAttributeValue(string)
-	SetAttributeType(AttributeType)
-	SetAttributeNamePosition(Position)
-	SetAttributeValuePosition(Position)
-	SetIsComponent(bool)
-	SetIsSelfClosing(bool)
+	NewAttribute(Position) error
+	SetName(string) error
+	AppendToName(rune) error
+	AppendToData(rune) error
+	SetAttributeName(string) error
+	AppendToAttributeName(rune) error
+	AppendToAttributeValue(rune) error
+	SetAttributeType(AttributeType) error
+	SetAttributeNamePosition(Position) error
+	SetAttributeValuePosition(Position) error
+	SetIsComponent(bool) error
+	SetIsSelfClosing(bool) error
 	Print()
 }
 
@@ -234,59 +257,52 @@ func (_self *EndOfFileToken) GetPosition() Position {
 // GetName()
 
 func (_self *StartTagToken) GetName() string {
-	return _self.name
+	return _self.name.String()
 }
 
 func (_self *EndTagToken) GetName() string {
-	return _self.name
+	return _self.name.String()
 }
 
 func (_self *CommentToken) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 func (_self *TextToken) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 func (_self *CodeToken) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 func (_self *EndOfFileToken) GetName() string {
-	utils.Assert(false, "token has name property", 2)
 	return ""
 }
 
 // GetData()
 
 func (_self *StartTagToken) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *EndTagToken) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
 func (_self *CommentToken) GetData() string {
-	return _self.data
+	return _self.data.String()
 }
 
 func (_self *TextToken) GetData() string {
-	return _self.data
+	return _self.data.String()
 }
 
 func (_self *CodeToken) GetData() string {
-	return _self.data
+	return _self.data.String()
 }
 
 func (_self *EndOfFileToken) GetData() string {
-	utils.Assert(false, "token has data property", 2)
 	return ""
 }
 
@@ -297,89 +313,84 @@ func (_self *StartTagToken) GetAttributes() []Attribute {
 }
 
 func (_self *EndTagToken) GetAttributes() []Attribute {
-	utils.Assert(false, "token has attributes property", 2)
 	return []Attribute{}
 }
 
 func (_self *CommentToken) GetAttributes() []Attribute {
-	utils.Assert(false, "token has attributes property", 2)
 	return []Attribute{}
 }
 
 func (_self *TextToken) GetAttributes() []Attribute {
-	utils.Assert(false, "token has attributes property", 2)
 	return []Attribute{}
 }
 
 func (_self *CodeToken) GetAttributes() []Attribute {
-	utils.Assert(false, "token has attributes property", 2)
 	return []Attribute{}
 }
 
 func (_self *EndOfFileToken) GetAttributes() []Attribute {
-	utils.Assert(false, "token has attributes property", 2)
 	return []Attribute{}
 }
 
 // GetAttributeType()
 
 func (_self *StartTagToken) GetAttributeType() AttributeType {
+	if len(_self.attributes) == 0 {
+		return ""
+	}
 	return _self.attributes[len(_self.attributes)-1].Type
 }
 
 func (_self *EndTagToken) GetAttributeType() AttributeType {
-	utils.Assert(false, "token has attributes property", 2)
 	return NormalAttribute
 }
 
 func (_self *CommentToken) GetAttributeType() AttributeType {
-	utils.Assert(false, "token has attributes property", 2)
 	return NormalAttribute
 }
 
 func (_self *TextToken) GetAttributeType() AttributeType {
-	utils.Assert(false, "token has attributes property", 2)
 	return NormalAttribute
 }
 
 func (_self *CodeToken) GetAttributeType() AttributeType {
-	utils.Assert(false, "token has attributes property", 2)
 	return NormalAttribute
 }
 
 func (_self *EndOfFileToken) GetAttributeType() AttributeType {
-	utils.Assert(false, "token has attributes property", 2)
 	return NormalAttribute
 }
 
 // GetIsSelfClosing()
 
+// lastAttribute is the attribute currently being lexed.
+func (_self *StartTagToken) lastAttribute() (*Attribute, error) {
+	if len(_self.attributes) == 0 {
+		return nil, errNoAttribute
+	}
+	return &_self.attributes[len(_self.attributes)-1], nil
+}
 func (_self *StartTagToken) GetIsSelfClosing() bool {
 	return _self.isSelfClosing
 }
 
 func (_self *EndTagToken) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *CommentToken) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *TextToken) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *CodeToken) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
 func (_self *EndOfFileToken) GetIsSelfClosing() bool {
-	utils.Assert(false, "token has isSelfClosing property", 2)
 	return false
 }
 
@@ -390,27 +401,22 @@ func (_self *StartTagToken) GetIsComponent() bool {
 }
 
 func (_self *EndTagToken) GetIsComponent() bool {
-	utils.Assert(false, "token has isComponent property", 2)
 	return false
 }
 
 func (_self *CommentToken) GetIsComponent() bool {
-	utils.Assert(false, "token has isComponent property", 2)
 	return false
 }
 
 func (_self *TextToken) GetIsComponent() bool {
-	utils.Assert(false, "token has isComponent property", 2)
 	return false
 }
 
 func (_self *CodeToken) GetIsComponent() bool {
-	utils.Assert(false, "token has isComponent property", 2)
 	return false
 }
 
 func (_self *EndOfFileToken) GetIsComponent() bool {
-	utils.Assert(false, "token has isComponent property", 2)
 	return false
 }
 
@@ -442,293 +448,377 @@ func (_self *EndOfFileToken) SetPosition(position Position) {
 
 // NewAttribute()
 
-func (_self *StartTagToken) NewAttribute(ln int, ch int) {
+// The name of the new attribute starts at position, which is empty, its
+// value has no position until the lexer sets one.
+func (_self *StartTagToken) NewAttribute(position Position) error {
+	_self.attributeName.Reset()
+	_self.attributeValue.Reset()
 	_self.attributes = append(_self.attributes, Attribute{
-		Type: NormalAttribute,
-		NamePosition: Position{
-			StartLine:   ln,
-			StartColumn: ch,
-			EndLine:     ln,
-			EndColumn:   ch - 1,
-		},
-		ValuePosition: Position{
-			StartLine:   ln,
-			StartColumn: ch + 2,
-			EndLine:     ln,
-			EndColumn:   ch + 1,
-		},
-		Name:  "",
-		Value: ""})
+		Type:          NormalAttribute,
+		NamePosition:  position,
+		ValuePosition: Position{},
+		Name:          "",
+		Value:         ""})
+	return nil
+}
+
+func (_self *EndTagToken) NewAttribute(position Position) error {
+	return noProperty(_self._type, "attributes")
+}
+
+func (_self *CommentToken) NewAttribute(position Position) error {
+	return noProperty(_self._type, "attributes")
+}
+
+func (_self *TextToken) NewAttribute(position Position) error {
+	return noProperty(_self._type, "attributes")
+}
+
+func (_self *CodeToken) NewAttribute(position Position) error {
+	return noProperty(_self._type, "attributes")
+}
+
+func (_self *EndOfFileToken) NewAttribute(position Position) error {
+	return noProperty(_self._type, "data")
+}
+
+// SetName()
+
+func (_self *StartTagToken) SetName(s string) error {
+	_self.name.Reset()
+	_self.name.WriteString(s)
+	return nil
 }
 
-func (_self *EndTagToken) NewAttribute(ln int, ch int) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) SetName(s string) error {
+	_self.name.Reset()
+	_self.name.WriteString(s)
+	return nil
 }
 
-func (_self *CommentToken) NewAttribute(ln int, ch int) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) SetName(s string) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *TextToken) NewAttribute(ln int, ch int) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) SetName(s string) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *CodeToken) NewAttribute(ln int, ch int) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) SetName(s string) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *EndOfFileToken) NewAttribute(ln int, ch int) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *EndOfFileToken) SetName(s string) error {
+	return noProperty(_self._type, "name")
 }
 
 // AppendToName()
 
-func (_self *StartTagToken) AppendToName(s string) {
-	_self.name = _self.name + s
+func (_self *StartTagToken) AppendToName(r rune) error {
+	_self.name.WriteRune(r)
+	return nil
 }
 
-func (_self *EndTagToken) AppendToName(s string) {
-	_self.name = _self.name + s
+func (_self *EndTagToken) AppendToName(r rune) error {
+	_self.name.WriteRune(r)
+	return nil
 }
 
-func (_self *CommentToken) AppendToName(s string) {
-	utils.Assert(false, "token has name property", 2)
+func (_self *CommentToken) AppendToName(r rune) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *TextToken) AppendToName(s string) {
-	utils.Assert(false, "token has name property", 2)
+func (_self *TextToken) AppendToName(r rune) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *CodeToken) AppendToName(s string) {
-	utils.Assert(false, "token has name property", 2)
+func (_self *CodeToken) AppendToName(r rune) error {
+	return noProperty(_self._type, "name")
 }
 
-func (_self *EndOfFileToken) AppendToName(s string) {
-	utils.Assert(false, "token has name property", 2)
+func (_self *EndOfFileToken) AppendToName(r rune) error {
+	return noProperty(_self._type, "name")
 }
 
 // AppendToData()
 
-func (_self *StartTagToken) AppendToData(s string) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *StartTagToken) AppendToData(r rune) error {
+	return noProperty(_self._type, "data")
+}
+
+func (_self *EndTagToken) AppendToData(r rune) error {
+	return noProperty(_self._type, "data")
+}
+
+func (_self *CommentToken) AppendToData(r rune) error {
+	_self.data.WriteRune(r)
+	return nil
+}
+
+func (_self *TextToken) AppendToData(r rune) error {
+	_self.data.WriteRune(r)
+	return nil
+}
+
+func (_self *CodeToken) AppendToData(r rune) error {
+	_self.data.WriteRune(r)
+	return nil
+}
+
+func (_self *EndOfFileToken) AppendToData(r rune) error {
+	return noProperty(_self._type, "data")
+}
+
+// SetAttributeName()
+
+func (_self *StartTagToken) SetAttributeName(s string) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	if runeCount(s) != runeCount(attribute.Name) {
+		return fmt.Errorf("cannot rename attribute %q to %q of a different length", attribute.Name, s)
+	}
+	_self.attributeName.Reset()
+	_self.attributeName.WriteString(s)
+	attribute.Name = _self.attributeName.String()
+	return nil
 }
 
-func (_self *EndTagToken) AppendToData(s string) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *EndTagToken) SetAttributeName(s string) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) AppendToData(s string) {
-	_self.data = _self.data + s
+func (_self *CommentToken) SetAttributeName(s string) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) AppendToData(s string) {
-	_self.data = _self.data + s
+func (_self *TextToken) SetAttributeName(s string) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) AppendToData(s string) {
-	_self.data = _self.data + s
+func (_self *CodeToken) SetAttributeName(s string) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) AppendToData(s string) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *EndOfFileToken) SetAttributeName(s string) error {
+	return noProperty(_self._type, "attributes")
 }
 
 // AppendToAttributeName()
 
-func (_self *StartTagToken) AppendToAttributeName(s string) {
-	utils.Assert(runeCount(s) == 1, "AppendToAttributeName(s string) where s is only 1 character", 2)
-	_self.attributes[len(_self.attributes)-1].Name = _self.attributes[len(_self.attributes)-1].Name + s
-	_self.attributes[len(_self.attributes)-1].NamePosition.EndColumn++
-	_self.attributes[len(_self.attributes)-1].ValuePosition.StartColumn++
-	_self.attributes[len(_self.attributes)-1].ValuePosition.EndColumn++
+func (_self *StartTagToken) AppendToAttributeName(r rune) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	_self.attributeName.WriteRune(r)
+	attribute.Name = _self.attributeName.String()
+	attribute.NamePosition.Extend(r)
+	return nil
 }
 
-func (_self *EndTagToken) AppendToAttributeName(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) AppendToAttributeName(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) AppendToAttributeName(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) AppendToAttributeName(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) AppendToAttributeName(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) AppendToAttributeName(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) AppendToAttributeName(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) AppendToAttributeName(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) AppendToAttributeName(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndOfFileToken) AppendToAttributeName(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
 // AppendToAttributeValue()
 
-func (_self *StartTagToken) AppendToAttributeValue(s string) {
-	utils.Assert(runeCount(s) == 1, "AppendToAttributeName(s string) where s is only 1 character", 2)
-	_self.attributes[len(_self.attributes)-1].Value = _self.attributes[len(_self.attributes)-1].Value + s
-	_self.attributes[len(_self.attributes)-1].ValuePosition.EndColumn++
+func (_self *StartTagToken) AppendToAttributeValue(r rune) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	_self.attributeValue.WriteRune(r)
+	attribute.Value = _self.attributeValue.String()
+	attribute.ValuePosition.Extend(r)
+	return nil
 }
 
-func (_self *EndTagToken) AppendToAttributeValue(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) AppendToAttributeValue(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) AppendToAttributeValue(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) AppendToAttributeValue(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) AppendToAttributeValue(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) AppendToAttributeValue(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) AppendToAttributeValue(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) AppendToAttributeValue(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) AppendToAttributeValue(s string) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndOfFileToken) AppendToAttributeValue(r rune) error {
+	return noProperty(_self._type, "attributes")
 }
 
 // SetAttributeType()
 
-func (_self *StartTagToken) SetAttributeType(t AttributeType) {
-	_self.attributes[len(_self.attributes)-1].Type = t
-	switch t {
-	case ArgumentAttribute:
-		_self.attributes[len(_self.attributes)-1].ValuePosition.StartLine = 0
-		_self.attributes[len(_self.attributes)-1].ValuePosition.StartColumn = 0
-		_self.attributes[len(_self.attributes)-1].ValuePosition.EndLine = 0
-		_self.attributes[len(_self.attributes)-1].ValuePosition.EndColumn = 0
-	default:
-		_self.attributes[len(_self.attributes)-1].ValuePosition.StartColumn--
-		_self.attributes[len(_self.attributes)-1].ValuePosition.EndColumn++
+func (_self *StartTagToken) SetAttributeType(t AttributeType) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	attribute.Type = t
+	if t == ArgumentAttribute {
+		attribute.ValuePosition = Position{}
 	}
+	return nil
 }
 
-func (_self *EndTagToken) SetAttributeType(t AttributeType) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) SetAttributeType(t AttributeType) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) SetAttributeType(t AttributeType) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) SetAttributeType(t AttributeType) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) SetAttributeType(t AttributeType) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) SetAttributeType(t AttributeType) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) SetAttributeType(t AttributeType) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) SetAttributeType(t AttributeType) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) SetAttributeType(t AttributeType) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndOfFileToken) SetAttributeType(t AttributeType) error {
+	return noProperty(_self._type, "attributes")
 }
 
 // SetAttributeNamePosition()
 
-func (_self *StartTagToken) SetAttributeNamePosition(position Position) {
-	_self.attributes[len(_self.attributes)-1].NamePosition = position
+func (_self *StartTagToken) SetAttributeNamePosition(position Position) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	attribute.NamePosition = position
+	return nil
 }
 
-func (_self *EndTagToken) SetAttributeNamePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) SetAttributeNamePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) SetAttributeNamePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) SetAttributeNamePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) SetAttributeNamePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) SetAttributeNamePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) SetAttributeNamePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) SetAttributeNamePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) SetAttributeNamePosition(position Position) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *EndOfFileToken) SetAttributeNamePosition(position Position) error {
+	return noProperty(_self._type, "data")
 }
 
 // SetAttributeValuePosition()
 
-func (_self *StartTagToken) SetAttributeValuePosition(position Position) {
-	_self.attributes[len(_self.attributes)-1].ValuePosition = position
+func (_self *StartTagToken) SetAttributeValuePosition(position Position) error {
+	var attribute, err = _self.lastAttribute()
+	if err != nil {
+		return err
+	}
+	attribute.ValuePosition = position
+	return nil
 }
 
-func (_self *EndTagToken) SetAttributeValuePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *EndTagToken) SetAttributeValuePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CommentToken) SetAttributeValuePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CommentToken) SetAttributeValuePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *TextToken) SetAttributeValuePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *TextToken) SetAttributeValuePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *CodeToken) SetAttributeValuePosition(position Position) {
-	utils.Assert(false, "token has attributes property", 2)
+func (_self *CodeToken) SetAttributeValuePosition(position Position) error {
+	return noProperty(_self._type, "attributes")
 }
 
-func (_self *EndOfFileToken) SetAttributeValuePosition(position Position) {
-	utils.Assert(false, "token has data property", 2)
+func (_self *EndOfFileToken) SetAttributeValuePosition(position Position) error {
+	return noProperty(_self._type, "data")
 }
 
 // SetIsComponent()
 
-func (_self *StartTagToken) SetIsComponent(b bool) {
+func (_self *StartTagToken) SetIsComponent(b bool) error {
 	_self.isComponent = b
+	return nil
 }
 
-func (_self *EndTagToken) SetIsComponent(b bool) {
-	utils.Assert(false, "token has isComponent property", 2)
+func (_self *EndTagToken) SetIsComponent(b bool) error {
+	return noProperty(_self._type, "isComponent")
 }
 
-func (_self *CommentToken) SetIsComponent(b bool) {
-	utils.Assert(false, "token has isComponent property", 2)
+func (_self *CommentToken) SetIsComponent(b bool) error {
+	return noProperty(_self._type, "isComponent")
 }
 
-func (_self *TextToken) SetIsComponent(b bool) {
-	utils.Assert(false, "token has isComponent property", 2)
+func (_self *TextToken) SetIsComponent(b bool) error {
+	return noProperty(_self._type, "isComponent")
 }
 
-func (_self *CodeToken) SetIsComponent(b bool) {
-	utils.Assert(false, "token has isComponent property", 2)
+func (_self *CodeToken) SetIsComponent(b bool) error {
+	return noProperty(_self._type, "isComponent")
 }
 
-func (_self *EndOfFileToken) SetIsComponent(b bool) {
-	utils.Assert(false, "token has isComponent property", 2)
+func (_self *EndOfFileToken) SetIsComponent(b bool) error {
+	return noProperty(_self._type, "isComponent")
 }
 
 // SetIsSelfClosing()
 
-func (_self *StartTagToken) SetIsSelfClosing(b bool) {
+func (_self *StartTagToken) SetIsSelfClosing(b bool) error {
 	_self.isSelfClosing = b
+	return nil
 }
 
-func (_self *EndTagToken) SetIsSelfClosing(b bool) {
-	utils.Assert(false, "token has isSelfClosing property", 2)
+func (_self *EndTagToken) SetIsSelfClosing(b bool) error {
+	return noProperty(_self._type, "isSelfClosing")
 }
 
-func (_self *CommentToken) SetIsSelfClosing(b bool) {
-	utils.Assert(false, "token has isSelfClosing property", 2)
+func (_self *CommentToken) SetIsSelfClosing(b bool) error {
+	return noProperty(_self._type, "isSelfClosing")
 }
 
-func (_self *TextToken) SetIsSelfClosing(b bool) {
-	utils.Assert(false, "token has isSelfClosing property", 2)
+func (_self *TextToken) SetIsSelfClosing(b bool) error {
+	return noProperty(_self._type, "isSelfClosing")
 }
 
-func (_self *CodeToken) SetIsSelfClosing(b bool) {
-	utils.Assert(false, "token has isSelfClosing property", 2)
+func (_self *CodeToken) SetIsSelfClosing(b bool) error {
+	return noProperty(_self._type, "isSelfClosing")
 }
 
-func (_self *EndOfFileToken) SetIsSelfClosing(b bool) {
-	utils.Assert(false, "token has isSelfClosing property", 2)
+func (_self *EndOfFileToken) SetIsSelfClosing(b bool) error {
+	return noProperty(_self._type, "isSelfClosing")
 }
 
 // Print()
@@ -745,7 +835,7 @@ func (_self *StartTagToken) Print() {
 	verbose.Printf(0, "%v", _self.position)
 	verbose.Printf(0, "%s\t%s\t%d Attributes\t%s\t%s\n",
 		_self._type,
-		_self.name,
+		_self.name.String(),
 		len(_self.attributes),
 		component,
 		selfClosing)
@@ -765,22 +855,22 @@ func (_self *StartTagToken) Print() {
 
 func (_self *EndTagToken) Print() {
 	verbose.Printf(0, "%v", _self.position)
-	verbose.Printf(0, "%s\t%s\n", _self._type, _self.name)
+	verbose.Printf(0, "%s\t%s\n", _self._type, _self.name.String())
 }
 
 func (_self *CommentToken) Print() {
 	verbose.Printf(0, "%v", _self.position)
-	verbose.Printf(0, "%s\t%s\n", _self._type, _self.data)
+	verbose.Printf(0, "%s\t%s\n", _self._type, _self.data.String())
 }
 
 func (_self *TextToken) Print() {
 	verbose.Printf(0, "%v", _self.position)
-	verbose.Printf(0, "%s   \t%s\n", _self._type, _self.data)
+	verbose.Printf(0, "%s   \t%s\n", _self._type, _self.data.String())
 }
 
 func (_self *CodeToken) Print() {
 	verbose.Printf(0, "%v", _self.position)
-	verbose.Printf(0, "%s\t%s\n", _self._type, _self.data)
+	verbose.Printf(0, "%s\t%s\n", _self._type, _self.data.String())
 }
 
 func (_self *EndOfFileToken) Print() {
@@ -0,0 +1,41 @@
+package tokens
+
+import (
+	"testing"
+)
+
+// TestMutatorErrors checks that setting a property a token does not have,
+// or an attribute before one is started, is an error.
+func TestMutatorErrors(t *testing.T) {
+	var text = NewTextToken(Position{})
+	if err := text.SetName("p"); err == nil || err.Error() != "Text token has no name property" {
+		t.Errorf("SetName on a Text token: got %v", err)
+	}
+	if err := text.AppendToData('x'); err != nil {
+		t.Errorf("AppendToData on a Text token: %v", err)
+	}
+	var startTag = NewStartTagToken(Position{})
+	if err := startTag.AppendToAttributeName('a'); err != errNoAttribute {
+		t.Errorf("AppendToAttributeName before NewAttribute: got %v", err)
+	}
+	if got := startTag.GetAttributeType(); got != "" {
+		t.Errorf("GetAttributeType before NewAttribute is %q", got)
+	}
+	if err := startTag.NewAttribute(Position{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range "Id" {
+		if err := startTag.AppendToAttributeName(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := startTag.SetAttributeName("idx"); err == nil {
+		t.Error("SetAttributeName to a longer name is not an error")
+	}
+	if err := startTag.SetAttributeName("id"); err != nil {
+		t.Error(err)
+	}
+	if got := startTag.GetAttributes()[0].Name; got != "id" {
+		t.Errorf("attribute name is %q, want id", got)
+	}
+}
@@ -0,0 +1,41 @@
+package stateparser
+
+import "strings"
+
+const (
+	svgNamespace    = "http://www.w3.org/2000/svg"
+	mathMLNamespace = "http://www.w3.org/1998/Math/MathML"
+	xlinkNamespace  = "http://www.w3.org/1999/xlink"
+	xmlNamespace    = "http://www.w3.org/XML/1998/namespace"
+	xmlnsNamespace  = "http://www.w3.org/2000/xmlns/"
+)
+
+// elementNamespace returns the namespace of an element called name whose
+// parent places its children in parentNamespace, and the namespace its own
+// children should be created in.
+func elementNamespace(name string, parentNamespace string) (string, string) {
+	switch {
+	case name == "svg":
+		return svgNamespace, svgNamespace
+	case name == "math":
+		return mathMLNamespace, mathMLNamespace
+	case name == "foreignObject" && parentNamespace == svgNamespace:
+		return svgNamespace, ""
+	}
+	return parentNamespace, parentNamespace
+}
+
+// attributeNamespace returns the namespace of a prefixed attribute such as
+// `xlink:href` or `xml:lang`, or "" for attributes without one.
+func attributeNamespace(name string) string {
+	var prefix, _, found = strings.Cut(name, ":")
+	switch {
+	case name == "xmlns" || (found && prefix == "xmlns"):
+		return xmlnsNamespace
+	case found && prefix == "xlink":
+		return xlinkNamespace
+	case found && prefix == "xml":
+		return xmlNamespace
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package stateparser
+
+import "testing"
+
+func TestElementNamespace(t *testing.T) {
+	var tests = []struct {
+		name            string
+		parentNamespace string
+		namespace       string
+		childNamespace  string
+	}{
+		{"div", "", "", ""},
+		{"svg", "", svgNamespace, svgNamespace},
+		{"circle", svgNamespace, svgNamespace, svgNamespace},
+		{"foreignObject", svgNamespace, svgNamespace, ""},
+		{"foreignObject", "", "", ""},
+		{"math", "", mathMLNamespace, mathMLNamespace},
+		{"mi", mathMLNamespace, mathMLNamespace, mathMLNamespace},
+	}
+	for _, test := range tests {
+		var namespace, childNamespace = elementNamespace(test.name, test.parentNamespace)
+		if namespace != test.namespace || childNamespace != test.childNamespace {
+			t.Errorf("<%s> in %q: got %q, %q, want %q, %q",
+				test.name, test.parentNamespace, namespace, childNamespace, test.namespace, test.childNamespace)
+		}
+	}
+}
+
+func TestAttributeNamespace(t *testing.T) {
+	var tests = map[string]string{
+		"href":        "",
+		"xlink:href":  xlinkNamespace,
+		"xml:lang":    xmlNamespace,
+		"xmlns":       xmlnsNamespace,
+		"xmlns:xlink": xmlnsNamespace,
+		"data-xml":    "",
+	}
+	for name, want := range tests {
+		if got := attributeNamespace(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestForeignObject checks that HTML inside foreignObject is created in no
+// namespace while its SVG siblings are.
+func TestForeignObject(t *testing.T) {
+	var got = compileView(t, `<svg><foreignObject><p>Hi</p></foreignObject><g></g></svg>`, Options{})
+	var want = `(*Elem).NewNS(nil, "http://www.w3.org/2000/svg", "svg")` +
+		`.Child((*Elem).NewNS(nil, "http://www.w3.org/2000/svg", "foreignObject").Child((*Elem).New(nil, "p").Text(` + "`Hi`" + `)))` +
+		`.Child((*Elem).NewNS(nil, "http://www.w3.org/2000/svg", "g"))`
+	if got != want {
+		t.Errorf("got:  %s\nwant: %s", got, want)
+	}
+}
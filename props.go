@@ -0,0 +1,51 @@
+package stateparser
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// propName turns an attribute name into the exported field of a component's
+// props struct, `label` => `Label`, `onClick` => `OnClick`, `aria-label` =>
+// `AriaLabel`.
+func propName(name string) string {
+	var field = ""
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_'
+	}) {
+		var r, size = utf8.DecodeRuneInString(part)
+		field = field + string(unicode.ToUpper(r)) + part[size:]
+	}
+	return field
+}
+
+// freeName returns base, or base followed by a number, such that it is not
+// an identifier of any of code. A variable the generated code declares under
+// that name cannot shadow one the code refers to.
+func freeName(base string, code ...string) string {
+	var used = make(map[string]interface{})
+	for _, source := range code {
+		var s scanner.Scanner
+		s.Init(token.NewFileSet().AddFile("", -1, len(source)), []byte(source), nil, 0)
+		for {
+			_, tok, literal := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if tok == token.IDENT {
+				used[literal] = nil
+			}
+		}
+	}
+	var name = base
+	for i := 1; ; i++ {
+		if _, ok := used[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+}
@@ -0,0 +1,58 @@
+package stateparser
+
+import "testing"
+
+func TestPropName(t *testing.T) {
+	var tests = map[string]string{
+		"label":      "Label",
+		"onClick":    "OnClick",
+		"aria-label": "AriaLabel",
+		"max_items":  "MaxItems",
+		"élan":       "Élan",
+	}
+	for name, want := range tests {
+		if got := propName(name); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestFreeName(t *testing.T) {
+	var tests = []struct {
+		code []string
+		want string
+	}{
+		{[]string{`"p"`, "fn(q)"}, "p"},
+		{[]string{"p.Name"}, "p1"},
+		{[]string{"p", "p1 + p2"}, "p3"},
+		{[]string{"props.p"}, "p1"},
+		{[]string{"pp"}, "p"},
+	}
+	for _, test := range tests {
+		if got := freeName("p", test.code...); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.code, got, test.want)
+		}
+	}
+}
+
+func TestComponentProps(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<Button />`, `Button.View(cx)`},
+		{`<Button label="Save" onClick={ save } disabled aria-label="s" />`,
+			`Button.View(cx, ButtonProps{Label: "Save", OnClick: save, Disabled: true, AriaLabel: "s"})`},
+		{`<Button { ...props } />`, `Button.View(cx, props)`},
+		{`<Button { ...props } label="ok" />`,
+			`Button.View(cx, func() ButtonProps { p := props; p.Label = "ok"; return p }())`},
+		{`<Button { ...props } label={ p.Label + "!" } />`,
+			`Button.View(cx, func() ButtonProps { p1 := props; p1.Label = p.Label + "!"; return p1 }())`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
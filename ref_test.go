@@ -0,0 +1,38 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRef(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<input ref={ input } />`,
+			`func() *Elem { e := (*Elem).New(nil, "input"); input.Set(e); return e }()`},
+		{`<div><input type="text" ref={ input } /></div>`,
+			`(*Elem).New(nil, "div").Child(func() *Elem { e := (*Elem).New(nil, "input"); input.Set(e); return e }().Attr("type", "text"))`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestRefHTML(t *testing.T) {
+	var got = compileView(t, `<input ref={ input } />`, Options{Backend: HTMLBackend{}})
+	if strings.Contains(got, "input.Set") {
+		t.Errorf("got %s, the HTML backend sets a ref", got)
+	}
+}
+
+func TestRefComponent(t *testing.T) {
+	var err = compileError(t, `<Input ref={ input } />`, Options{})
+	if !strings.Contains(err.Error(), "ref is not supported on components") {
+		t.Errorf("got %v, want ref is not supported on components", err)
+	}
+}
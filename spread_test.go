@@ -0,0 +1,58 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goptos/stateparser/lexer"
+	"github.com/goptos/stateparser/lexer/tokens"
+)
+
+func TestSpreadToken(t *testing.T) {
+	for _, source := range []string{`<input {...attrs} />`, `<input { ...attrs } />`, `<Input {...props} />`} {
+		var lex = lexer.New(source)
+		err := lex.Tokenise()
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+		var attributes = lex.Tokens[0].GetAttributes()
+		if len(attributes) != 1 || attributes[0].Type != tokens.SpreadAttribute {
+			t.Fatalf("%s: got %+v, want one spread attribute", source, attributes)
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<input type="text" {...attrs} />`,
+			`(*Elem).New(nil, "input").Attr("type", "text").SpreadAttrs(attrs)`},
+		{`<Input {...props} />`, `Input.View(cx, props)`},
+		{`<Input {...props} label="a" />`,
+			`Input.View(cx, func() InputProps { p := props; p.Label = "a"; return p }())`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestSpreadErrors(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<input { attrs } />`, "invalid-spread-attribute"},
+		{`<Input {...a} {...b} />`, "<Input> can only spread one props value"},
+	}
+	for _, test := range tests {
+		var err = compileError(t, test.source, Options{})
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %s", test.source, err, test.want)
+		}
+	}
+}
@@ -21,13 +21,23 @@ func (_self *Stack[T]) Push(t T) {
 	_self.pointer = len(_self.stack) - 1
 }
 
-func (_self *Stack[T]) Peak() T {
-	return _self.stack[_self.pointer]
+// Peak returns the top of the stack, false if the stack is empty.
+func (_self *Stack[T]) Peak() (T, bool) {
+	if _self.pointer < 0 {
+		var zero T
+		return zero, false
+	}
+	return _self.stack[_self.pointer], true
 }
 
-func (_self *Stack[T]) Pop() T {
+// Pop removes and returns the top of the stack, false if the stack is empty.
+func (_self *Stack[T]) Pop() (T, bool) {
+	if _self.pointer < 0 {
+		var zero T
+		return zero, false
+	}
 	var tmp = _self.stack[_self.pointer]
 	_self.stack = _self.stack[0:_self.pointer]
 	_self.pointer = len(_self.stack) - 1
-	return tmp
+	return tmp, true
 }
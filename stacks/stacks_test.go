@@ -0,0 +1,34 @@
+package stacks
+
+import (
+	"testing"
+)
+
+func TestStack(t *testing.T) {
+	var stack = New[int]()
+	if _, ok := stack.Peak(); ok {
+		t.Error("Peak of an empty stack is ok")
+	}
+	if _, ok := stack.Pop(); ok {
+		t.Error("Pop of an empty stack is ok")
+	}
+	stack.Push(1)
+	stack.Push(2)
+	if got := stack.Depth(); got != 1 {
+		t.Errorf("Depth is %d, want 1", got)
+	}
+	for _, want := range []int{2, 1} {
+		if got, ok := stack.Peak(); !ok || got != want {
+			t.Errorf("Peak is %d %t, want %d true", got, ok, want)
+		}
+		if got, ok := stack.Pop(); !ok || got != want {
+			t.Errorf("Pop is %d %t, want %d true", got, ok, want)
+		}
+	}
+	if got, ok := stack.Pop(); ok || got != 0 {
+		t.Errorf("Pop of the emptied stack is %d %t, want 0 false", got, ok)
+	}
+	if got := stack.Depth(); got != -1 {
+		t.Errorf("Depth is %d, want -1", got)
+	}
+}
@@ -1,258 +1,609 @@
 package stateparser
 
 import (
+	"errors"
 	"fmt"
+	"go/token"
+	"html"
+	"io"
 	"strings"
 
 	"github.com/goptos/stateparser/ast"
 	"github.com/goptos/stateparser/ast/nodes"
+	"github.com/goptos/stateparser/lexer/tokens"
 	"github.com/goptos/stateparser/stacks"
 )
 
 type nodeInfo struct {
-	isEach          bool
-	hasIf           bool
+	isEachView      bool
 	isComponent     bool
+	isSlot          bool
 	isSelfClosing   bool
-	ifFunction      string
-	collectFunction string
-	keyFunction     string
-	viewComponent   string
+	isOpen          bool
+	attach          func(statement string) string
+	attachDirective string
+	finish          []func(statement string) string
+	eachView        string
+	namespace       string
+	childNamespace  string
+	selectValue     string
+	props           []string
+	spread          string
+	name            string
+	attributes      map[string]string
+	classToggles    []ClassToggle
+	styleProperties []StyleProperty
+	hasClass        bool
+	hasStyle        bool
+	path            string
+	childCount      int
+	regions         int
 }
 
 type Parser struct {
-	Ast        *ast.Ast
-	Result     string
-	statements stacks.Stack[string]
-	nodeInfo   stacks.Stack[nodeInfo]
+	Ast            *ast.Ast
+	Backend        Backend
+	Result         string
+	Hydrate        bool
+	Hoist          bool
+	Statics        []string
+	StaticPrefix   string
+	staticNames    map[string]string
+	statements     stacks.Stack[*strings.Builder]
+	nodeInfo       stacks.Stack[nodeInfo]
+	directives     map[string]DirectiveHandler
+	directiveNames []string
+	err            error
 }
 
+var (
+	errNoNode      = errors.New("unbalanced view: no element is open")
+	errNoStatement = errors.New("unbalanced view: no statement is open")
+)
+
 func New() *Parser {
-	return &Parser{
-		Ast:        nil,
-		Result:     "",
-		statements: stacks.New[string](),
-		nodeInfo:   stacks.New[nodeInfo](),
+	var parser = &Parser{
+		Ast:            nil,
+		Backend:        GoptosBackend{},
+		Result:         "",
+		Hydrate:        false,
+		Hoist:          false,
+		Statics:        []string{},
+		StaticPrefix:   "",
+		staticNames:    make(map[string]string),
+		statements:     stacks.New[*strings.Builder](),
+		nodeInfo:       stacks.New[nodeInfo](),
+		directives:     make(map[string]DirectiveHandler),
+		directiveNames: []string{},
 	}
+	parser.registerBuiltinDirectives()
+	return parser
 }
 
 func (_self *Parser) reset() {
 	_self.Ast = nil
 	_self.Result = ""
-	_self.statements = stacks.New[string]()
+	_self.Statics = []string{}
+	_self.staticNames = make(map[string]string)
+	_self.statements = stacks.New[*strings.Builder]()
 	_self.nodeInfo = stacks.New[nodeInfo]()
+	_self.err = nil
 }
 
 func (_self *Parser) updateNodeInfo(node nodes.Node) {
 	var nodeInfo = nodeInfo{
-		isEach:          false,
-		hasIf:           false,
+		isEachView:      false,
+		isComponent:     node.GetType() == nodes.Component,
+		isSlot:          false,
 		isSelfClosing:   node.GetIsSelfClosing(),
-		ifFunction:      "",
-		collectFunction: "",
-		keyFunction:     "",
-		viewComponent:   "",
+		isOpen:          false,
+		attach:          nil,
+		attachDirective: "",
+		finish:          nil,
+		eachView:        "",
+		namespace:       "",
+		childNamespace:  "",
+		selectValue:     "",
+		props:           []string{},
+		spread:          "",
+		name:            node.GetName(),
+		attributes:      make(map[string]string),
+		classToggles:    []ClassToggle{},
+		styleProperties: []StyleProperty{},
+		hasClass:        false,
+		hasStyle:        false,
+		path:            _self.childPath(),
+		childCount:      0,
+		regions:         0,
+	}
+	if node.GetType() == nodes.StartElement {
+		var parentNamespace = ""
+		if _self.nodeInfo.Depth() >= 0 {
+			parentNamespace = _self.top().childNamespace
+			nodeInfo.selectValue = _self.top().selectValue
+		}
+		nodeInfo.namespace, nodeInfo.childNamespace = elementNamespace(node.GetName(), parentNamespace)
 	}
 	for _, childNode := range node.GetChildren() {
 		switch childNode.GetType() {
-		case nodes.KeywordAttribute:
-			if childNode.GetName() == "if" {
-				nodeInfo.ifFunction = childNode.GetEffect()
-			}
-			if childNode.GetName() == "each" {
-				nodeInfo.collectFunction = childNode.GetEffect()
-			}
-			if childNode.GetName() == "key" {
-				nodeInfo.keyFunction = childNode.GetEffect()
-			}
-		case nodes.Component:
-			if childNode.GetIsSelfClosing() {
-				nodeInfo.viewComponent = childNode.GetName()
+		case nodes.Attribute:
+			nodeInfo.attributes[childNode.GetName()] = childNode.GetValue()
+		case nodes.DynAttribute:
+			switch childNode.GetName() {
+			case "class":
+				nodeInfo.classToggles = append(nodeInfo.classToggles,
+					ClassToggle{Name: childNode.GetValue(), Condition: strings.TrimSpace(childNode.GetEffect())})
+			case "style":
+				nodeInfo.styleProperties = append(nodeInfo.styleProperties,
+					StyleProperty{Name: childNode.GetValue(), Value: strings.TrimSpace(childNode.GetEffect())})
+			case "bind":
+				if node.GetName() == "select" && childNode.GetValue() == "value" {
+					nodeInfo.selectValue = strings.TrimSpace(childNode.GetEffect())
+				}
 			}
 		}
 	}
-	if nodeInfo.collectFunction != "" &&
-		nodeInfo.keyFunction != "" &&
-		nodeInfo.viewComponent != "" {
-		nodeInfo.isEach = true
+	_self.nodeInfo.Push(nodeInfo)
+}
+
+// classAttribute writes the class attribute of the element at the top of the
+// nodeInfo stack, its static value and its class: directives together, where
+// the first of them appears.
+func (_self *Parser) classAttribute() {
+	var nodeInfo = _self.popNodeInfo()
+	if !nodeInfo.hasClass {
+		nodeInfo.hasClass = true
+		_self.appendToStatement("%s", _self.Backend.Class(nodeInfo.attributes["class"], nodeInfo.classToggles))
 	}
-	if nodeInfo.ifFunction != "" {
-		nodeInfo.hasIf = true
+	_self.nodeInfo.Push(nodeInfo)
+}
+
+// styleAttribute writes the style attribute of the element at the top of the
+// nodeInfo stack like classAttribute.
+func (_self *Parser) styleAttribute() {
+	var nodeInfo = _self.popNodeInfo()
+	if !nodeInfo.hasStyle {
+		nodeInfo.hasStyle = true
+		_self.appendToStatement("%s", _self.Backend.Style(nodeInfo.attributes["style"], nodeInfo.styleProperties))
 	}
 	_self.nodeInfo.Push(nodeInfo)
 }
 
+// Statements are built in place, appending the children of an element with
+// many children copies each of them once rather than the whole statement.
 func (_self *Parser) newStatement(s string, args ...interface{}) {
-	_self.statements.Push(fmt.Sprintf(s, args...))
+	var statement = &strings.Builder{}
+	fmt.Fprintf(statement, s, args...)
+	_self.statements.Push(statement)
 }
 
 func (_self *Parser) appendToStatement(s string, args ...interface{}) {
-	_self.statements.Push(_self.statements.Pop() + fmt.Sprintf(s, args...))
+	fmt.Fprintf(_self.statement(), s, args...)
 }
 
 func (_self *Parser) prependToStatement(s string, args ...interface{}) {
-	_self.statements.Push(fmt.Sprintf(s, args...) + _self.statements.Pop())
+	var statement = _self.popStatement()
+	_self.newStatement(s, args...)
+	_self.statement().WriteString(statement)
+}
+
+func (_self *Parser) popStatement() string {
+	var statement, ok = _self.statements.Pop()
+	if !ok {
+		_self.fail(errNoStatement)
+		return ""
+	}
+	return statement.String()
 }
 
-func (_self *Parser) statementContains(s string, args ...interface{}) bool {
-	if _self.statements.Depth() < 0 {
-		return false
+// statement is the statement being built, a detached one once the view is
+// unbalanced so that parsing can carry on until the error is returned.
+func (_self *Parser) statement() *strings.Builder {
+	var statement, ok = _self.statements.Peak()
+	if !ok {
+		_self.fail(errNoStatement)
+		return &strings.Builder{}
+	}
+	return statement
+}
+
+// top is the node at the top of the nodeInfo stack.
+func (_self *Parser) top() nodeInfo {
+	var nodeInfo, ok = _self.nodeInfo.Peak()
+	if !ok {
+		_self.fail(errNoNode)
+	}
+	return nodeInfo
+}
+
+func (_self *Parser) popNodeInfo() nodeInfo {
+	var nodeInfo, ok = _self.nodeInfo.Pop()
+	if !ok {
+		_self.fail(errNoNode)
+	}
+	return nodeInfo
+}
+
+// fail records the first error of a helper that cannot return one, the view
+// fails with it once it has been processed.
+func (_self *Parser) fail(err error) {
+	if _self.err == nil {
+		_self.err = err
 	}
-	return strings.Contains(_self.statements.Peak(), fmt.Sprintf(s, args...))
 }
 
 func (_self *Parser) squashStatement() {
-	if _self.statements.Depth() == 0 && _self.nodeInfo.Peak().hasIf {
-		_self.prependToStatement("`<invalid view: if statements on root elements are not supported>` //")
+	if _self.statements.Depth() == 0 && _self.top().attach != nil {
+		_self.prependToStatement("`<invalid view: %s statements on root elements are not supported>` //",
+			_self.top().attachDirective)
 	}
 	if _self.statements.Depth() == 0 {
 		return
 	}
-	var statement = _self.statements.Pop()
-	if _self.nodeInfo.Peak().hasIf {
-		_self.appendToStatement(".DynChild(cx, %s, %s)",
-			_self.nodeInfo.Peak().ifFunction,
-			statement)
+	var statement = _self.popStatement()
+	if _self.top().attach != nil {
+		_self.appendToStatement("%s", _self.top().attach(statement))
 		return
 	}
-	_self.appendToStatement(".Child(%s)",
-		statement)
+	_self.appendToStatement("%s", _self.Backend.Child(statement))
+}
+
+// childPath numbers the next child of the node at the top of the nodeInfo
+// stack, `0.2.1` is the second child of the third child of the root.
+func (_self *Parser) childPath() string {
+	if _self.nodeInfo.Depth() < 0 {
+		return "0"
+	}
+	var nodeInfo = _self.popNodeInfo()
+	nodeInfo.childCount++
+	_self.nodeInfo.Push(nodeInfo)
+	return fmt.Sprintf("%s.%d", nodeInfo.path, nodeInfo.childCount-1)
+}
+
+// regionID is the hydration ID of the dynamic region at path, empty unless
+// the parser hydrates.
+func (_self *Parser) regionID(path string) string {
+	if !_self.Hydrate {
+		return ""
+	}
+	return path
 }
 
+// openElement ends the start tag of the element at the top of the nodeInfo
+// stack once its attributes are done, before its first child or its end.
+func (_self *Parser) openElement() {
+	if _self.nodeInfo.Depth() < 0 {
+		return
+	}
+	var nodeInfo = _self.top()
+	if nodeInfo.isComponent || nodeInfo.isSlot || nodeInfo.isOpen {
+		return
+	}
+	nodeInfo = _self.popNodeInfo()
+	nodeInfo.isOpen = true
+	_self.nodeInfo.Push(nodeInfo)
+	if value, ok := nodeInfo.attributes["value"]; ok && nodeInfo.name == "option" && nodeInfo.selectValue != "" {
+		_self.appendToStatement("%s", _self.Backend.Selected(nodeInfo.selectValue, html.UnescapeString(value)))
+	}
+	_self.appendToStatement("%s", _self.Backend.OpenElement())
+}
+
+// addProp sets field of the props of the component at the top of the
+// nodeInfo stack, a field can only be set once and must be a Go identifier.
+func (_self *Parser) addProp(field string, value string) error {
+	err := _self.checkField(field)
+	if err != nil {
+		return err
+	}
+	var nodeInfo = _self.popNodeInfo()
+	for _, prop := range nodeInfo.props {
+		if strings.HasPrefix(prop, field+": ") {
+			_self.nodeInfo.Push(nodeInfo)
+			return fmt.Errorf("<%s> sets the %s prop more than once", nodeInfo.name, field)
+		}
+	}
+	nodeInfo.props = append(nodeInfo.props, field+": "+value)
+	_self.nodeInfo.Push(nodeInfo)
+	return nil
+}
+
+// checkField checks that field can name a prop of the component at the top
+// of the nodeInfo stack.
+func (_self *Parser) checkField(field string) error {
+	if !token.IsIdentifier(field) {
+		return fmt.Errorf("<%s> cannot set the %q prop, it is not a Go field name", _self.top().name, field)
+	}
+	return nil
+}
+
+// checkValues checks that every attribute of node taking Go code has some,
+// an attribute without it generates code that does not compile. Directives
+// check their own values, one may be a flag.
+func checkValues(node nodes.Node) error {
+	for _, childNode := range node.GetChildren() {
+		var name string
+		switch childNode.GetType() {
+		case nodes.DynAttribute:
+			name = childNode.GetName() + ":" + childNode.GetValue()
+		case nodes.EventAttribute:
+			name = childNode.GetName() + ":" + childNode.GetEvent()
+		case nodes.ExpressionAttribute:
+			name = childNode.GetName()
+		case nodes.SpreadAttribute:
+			name = "{...}"
+		default:
+			continue
+		}
+		if strings.TrimSpace(childNode.GetEffect()) == "" {
+			return &tokens.Error{
+				Position: childNode.GetPosition(),
+				Err:      fmt.Errorf("%s has no Go expression", name)}
+		}
+	}
+	return nil
+}
+
+// addSlot passes the children builder value to the component at the top of
+// the nodeInfo stack in the field the Backend names after the slot field.
+func (_self *Parser) addSlot(field string, value string) error {
+	err := _self.checkField(field)
+	if err != nil {
+		return err
+	}
+	var slot = _self.Backend.SlotProp(field)
+	for _, prop := range _self.top().props {
+		if strings.HasPrefix(prop, field+": ") || strings.HasPrefix(prop, slot+": ") {
+			return fmt.Errorf("<%s> sets the %s prop more than once", _self.top().name, field)
+		}
+	}
+	return _self.addProp(slot, value)
+}
+
+// ParseView generates the code of the view in source into Result. Every
+// construct of the syntax has a template and its output in testdata/golden.
 func (_self *Parser) ParseView(source string) error {
+	return _self.ParseViewReader(strings.NewReader(source))
+}
+
+// ParseViewReader parses the view read from r, the source is lexed as the
+// AST is built instead of being read up front.
+func (_self *Parser) ParseViewReader(r io.Reader) error {
 	_self.reset()
-	_self.Ast = ast.New(source)
-	_self.Ast.AddKeywordAttributeName("if")
-	_self.Ast.AddKeywordAttributeName("each")
-	_self.Ast.AddKeywordAttributeName("key")
-	/*
-		`<div>` => `(*Elem).New(nil, "div")`
-	*/
+	_self.Ast = ast.NewReader(r)
+	for _, name := range _self.directiveNames {
+		_self.Ast.AddKeywordAttributeName(name)
+	}
 	_self.Ast.StartElementNodeProcessor = func(node *nodes.StartElementNode, depth *int) error {
 		(*nodes.StartElementNode).Print(node, depth)
-		_self.updateNodeInfo(node)
-		_self.newStatement("(*Elem).New(nil, \"%s\")", node.GetName())
+		err := checkValues(node)
+		if err != nil {
+			return err
+		}
+		_self.openElement()
+		if _self.hoist(node) {
+			return ast.SkipChildren
+		}
 		/*
-			`<ul each={cF} key={kF}><Li /></ul>` =>
-			`system.Each((*Elem).New(nil, "ul"), cx, cF, kF, Li.View)`
+			`<Card><slot:header>Hi</slot:header></Card>` =>
+			`Card.View(cx, CardProps{Header: func(e *Elem) *Elem { return e.Text("Hi") }})`
 		*/
-		if _self.nodeInfo.Peak().isEach {
-			_self.prependToStatement("system.Each(")
-			_self.appendToStatement(", cx, %s, %s, %s.View)",
-				_self.nodeInfo.Peak().collectFunction,
-				_self.nodeInfo.Peak().keyFunction,
-				_self.nodeInfo.Peak().viewComponent)
-		}
-		if node.GetIsSelfClosing() {
-			_self.squashStatement()
-			_self.nodeInfo.Pop()
+		if strings.HasPrefix(node.GetName(), "slot:") {
+			if _self.nodeInfo.Depth() < 0 ||
+				!_self.top().isComponent ||
+				_self.top().isEachView {
+				return fmt.Errorf("<%s> must be a direct child of a component", node.GetName())
+			}
+			_self.updateNodeInfo(node)
+			var nodeInfo = _self.popNodeInfo()
+			nodeInfo.isSlot = true
+			_self.nodeInfo.Push(nodeInfo)
+			_self.newStatement("%s", _self.Backend.ChildBuilder())
+			return nil
 		}
-		return nil
+		_self.updateNodeInfo(node)
+		_self.newStatement("%s", _self.Backend.Element(_self.top().namespace, node.GetName()))
+		return _self.applyDirectives(node)
 	}
 	/*
-		`<Button arg1 arg2 />` => `Button.View(cx, arg1, arg2)`
+		`<Button label="Save" onClick={fn} disabled />` =>
+		`Button.View(cx, ButtonProps{Label: "Save", OnClick: fn, Disabled: true})`
+
+		`<Card><p>Hi</p></Card>` =>
+		`Card.View(cx, CardProps{Children: func(e *Elem) *Elem { return e.Child(...) }})`
 	*/
 	_self.Ast.ComponentNodeProcessor = func(node *nodes.ComponentNode, depth *int) error {
 		(*nodes.ComponentNode).Print(node, depth)
-		if _self.statementContains(", %s.View)", node.GetName()) {
-			return nil
+		if !token.IsIdentifier(node.GetName()) {
+			return fmt.Errorf("<%s> is not a component, its name is not a Go identifier", node.GetName())
+		}
+		err := checkValues(node)
+		if err != nil {
+			return err
 		}
+		_self.openElement()
+		var isEachView = _self.nodeInfo.Depth() >= 0 && _self.top().eachView == node.GetName()
 		_self.updateNodeInfo(node)
-		_self.newStatement("%s.View(cx", node.GetName())
+		if isEachView {
+			var nodeInfo = _self.popNodeInfo()
+			nodeInfo.isEachView = true
+			_self.nodeInfo.Push(nodeInfo)
+			return nil
+		}
 		for _, childNode := range node.GetChildren() {
+			var err error
 			switch childNode.GetType() {
 			case nodes.Attribute:
-				_self.appendToStatement(", %s", childNode.GetName())
+				err = _self.addProp(propName(childNode.GetName()),
+					fmt.Sprintf("%q", html.UnescapeString(childNode.GetValue())))
+			case nodes.ArgumentAttribute:
+				err = _self.addProp(propName(childNode.GetName()), "true")
+			case nodes.ExpressionAttribute:
+				err = _self.addProp(propName(childNode.GetName()),
+					strings.TrimSpace(childNode.GetEffect()))
+			case nodes.SpreadAttribute:
+				if _self.top().spread != "" {
+					return fmt.Errorf("<%s> can only spread one props value", node.GetName())
+				}
+				var nodeInfo = _self.popNodeInfo()
+				nodeInfo.spread = childNode.GetEffect()
+				_self.nodeInfo.Push(nodeInfo)
+			}
+			if err != nil {
+				return err
 			}
 		}
-		_self.appendToStatement(")")
 		if node.GetIsSelfClosing() {
-			_self.squashStatement()
-			_self.nodeInfo.Pop()
+			_self.newStatement("%s", _self.Backend.Component(node.GetName(),
+				_self.top().props,
+				_self.top().spread))
+			return _self.applyDirectives(node)
 		}
+		_self.newStatement("%s", _self.Backend.ChildBuilder())
 		return nil
 	}
-	/*
-		`Hello` => `.Text("Hello")`
-	*/
 	_self.Ast.TextNodeProcessor = func(node *nodes.TextNode, depth *int) error {
 		(*nodes.TextNode).Print(node, depth)
-		_self.appendToStatement(".Text(`%s`)",
-			node.GetData())
+		_self.openElement()
+		_self.childPath()
+		_self.appendToStatement("%s", _self.Backend.Text(node.GetData()))
 		return nil
 	}
-	/*
-		`{count.Get()}` => `.DynText(cx, func() string { return fmt.Sprintf("%v", count.Get()) })`
-	*/
 	_self.Ast.DynTextNodeProcessor = func(node *nodes.DynTextNode, depth *int) error {
 		(*nodes.DynTextNode).Print(node, depth)
-		var effect = node.GetEffect()
-		// ugly bug gets the job done for now
-		effect = strings.Trim(effect, " ")
-		effect = strings.Trim(effect, "\t")
-		effect = strings.Trim(effect, "\r")
-		effect = strings.Trim(effect, "\n") // not...
-		effect = strings.Trim(effect, " ")
-		effect = strings.Trim(effect, "\t")
-		effect = strings.Trim(effect, "\r")
-		effect = strings.Trim(effect, "\n") // ... a...
-		effect = strings.Trim(effect, " ")
-		effect = strings.Trim(effect, "\t")
-		effect = strings.Trim(effect, "\r")
-		effect = strings.Trim(effect, "\n") // ... copy/paste...
-		effect = strings.Trim(effect, " ")
-		effect = strings.Trim(effect, "\t")
-		effect = strings.Trim(effect, "\r")
-		effect = strings.Trim(effect, "\n") // ... mistake!
-		if strings.Split(effect, " ")[0] == "func()" {
-			_self.appendToStatement(".DynText(cx, %s)", effect)
-			return nil
+		if strings.TrimSpace(node.GetEffect()) == "" {
+			return fmt.Errorf("{} has no Go expression")
 		}
-		_self.appendToStatement(".DynText(cx, func() string { return fmt.Sprintf(\"%%v\", %s) })",
-			node.GetEffect())
+		_self.openElement()
+		_self.appendToStatement("%s", _self.Backend.DynText(_self.regionID(_self.childPath()),
+			strings.TrimSpace(node.GetEffect())))
 		return nil
 	}
-	/*
-		`id="sub-button"` => `.Attr("id", "sub-button")`
-	*/
 	_self.Ast.AttributeNodeProcessor = func(node *nodes.AttributeNode, depth *int) error {
 		(*nodes.AttributeNode).Print(node, depth)
-		if _self.nodeInfo.Peak().isComponent {
+		if _self.top().isComponent {
+			return nil
+		}
+		switch node.GetName() {
+		case "class":
+			_self.classAttribute()
+			return nil
+		case "style":
+			_self.styleAttribute()
 			return nil
 		}
-		_self.appendToStatement(".Attr(\"%s\", \"%s\")",
+		_self.appendToStatement("%s", _self.Backend.Attr(attributeNamespace(node.GetName()),
 			node.GetName(),
-			node.GetValue())
+			node.GetValue()))
 		return nil
 	}
 	/*
-		`on:click={ func(Event) {} }` => `.On("click", func(Event))`
+		`disabled` => `.Attr("disabled", "")`
 	*/
+	_self.Ast.ArgumentAttributeNodeProcessor = func(node *nodes.ArgumentAttributeNode, depth *int) error {
+		(*nodes.ArgumentAttributeNode).Print(node, depth)
+		if _self.top().isComponent {
+			return nil
+		}
+		_self.appendToStatement("%s", _self.Backend.Attr("", node.GetName(), ""))
+		return nil
+	}
+	_self.Ast.ExpressionAttributeNodeProcessor = func(node *nodes.ExpressionAttributeNode, depth *int) error {
+		(*nodes.ExpressionAttributeNode).Print(node, depth)
+		if _self.top().isComponent {
+			return nil
+		}
+		_self.appendToStatement("%s", _self.Backend.DynAttrValue(strings.TrimSpace(node.GetEffect()),
+			node.GetName()))
+		return nil
+	}
+	_self.Ast.SpreadAttributeNodeProcessor = func(node *nodes.SpreadAttributeNode, depth *int) error {
+		(*nodes.SpreadAttributeNode).Print(node, depth)
+		if _self.top().isComponent {
+			return nil
+		}
+		_self.appendToStatement("%s", _self.Backend.SpreadAttrs(node.GetEffect()))
+		return nil
+	}
 	_self.Ast.EventAttributeNodeProcessor = func(node *nodes.EventAttributeNode, depth *int) error {
 		(*nodes.EventAttributeNode).Print(node, depth)
-		_self.appendToStatement(".On(\"%s\", %s)",
-			node.GetEvent(),
-			node.GetEffect())
+		if _self.top().isComponent {
+			return fmt.Errorf("on:%s is not supported on components, pass the handler as a prop", node.GetEvent())
+		}
+		statement, err := _self.Backend.Event(node.GetEvent(),
+			node.GetModifiers(),
+			strings.TrimSpace(node.GetEffect()))
+		if err != nil {
+			return err
+		}
+		_self.appendToStatement("%s", statement)
 		return nil
 	}
 	/*
-		`class:dark={ func() bool {} }` => `.DynAttr("class", "dark", func() bool)`
+		`class:dark={fn}`, `style:color={fn}` and `bind:value={signal}`
 	*/
 	_self.Ast.DynAttributeNodeProcessor = func(node *nodes.DynAttributeNode, depth *int) error {
 		(*nodes.DynAttributeNode).Print(node, depth)
-		_self.appendToStatement(".DynAttr(cx, %s, \"%s\", \"%s\")",
-			node.GetEffect(),
-			node.GetName(),
-			node.GetValue())
+		if _self.top().isComponent {
+			return fmt.Errorf("%s:%s is not supported on components", node.GetName(), node.GetValue())
+		}
+		switch node.GetName() {
+		case "bind":
+			statement, err := _self.Backend.Bind(node.GetValue(),
+				strings.TrimSpace(node.GetEffect()),
+				_self.top().name,
+				_self.top().attributes)
+			if err != nil {
+				return err
+			}
+			_self.appendToStatement("%s", statement)
+		case "style":
+			_self.styleAttribute()
+		default:
+			_self.classAttribute()
+		}
 		return nil
 	}
 	/*
-		</...>
+		`</...>` and `/>`
 	*/
 	_self.Ast.EndElementNodeProcessor = func(node *nodes.EndElementNode, depth *int) error {
 		(*nodes.EndElementNode).Print(node, depth)
+		if _self.top().isEachView {
+			_self.popNodeInfo()
+			return nil
+		}
+		if _self.top().isSlot {
+			var children = _self.popStatement()
+			_self.popNodeInfo()
+			return _self.addSlot(propName(strings.TrimPrefix(node.GetName(), "slot:")),
+				_self.Backend.EndChildBuilder(children))
+		}
+		if _self.top().isComponent && !_self.top().isSelfClosing {
+			var children = _self.popStatement()
+			if children != _self.Backend.ChildBuilder() {
+				err := _self.addSlot("Children", _self.Backend.EndChildBuilder(children))
+				if err != nil {
+					return err
+				}
+			}
+			_self.newStatement("%s", _self.Backend.Component(node.GetName(),
+				_self.top().props,
+				_self.top().spread))
+			err := _self.applyDirectives(node.GetStartElementNode())
+			if err != nil {
+				return err
+			}
+		}
+		_self.openElement()
+		for _, finish := range _self.top().finish {
+			_self.newStatement("%s", finish(_self.popStatement()))
+		}
+		if !_self.top().isComponent {
+			_self.appendToStatement("%s", _self.Backend.CloseElement(_self.top().namespace,
+				node.GetName()))
+		}
 		_self.squashStatement()
-		_self.nodeInfo.Pop()
+		_self.popNodeInfo()
 		return nil
 	}
 	err := _self.Ast.Create()
@@ -263,6 +614,10 @@ func (_self *Parser) ParseView(source string) error {
 	if err != nil {
 		return err
 	}
-	_self.Result = _self.statements.Pop() + "\r"
+	_self.Result = _self.popStatement() + "\r"
+	if _self.err != nil {
+		_self.Result = ""
+		return _self.err
+	}
 	return nil
 }
@@ -0,0 +1,230 @@
+package stateparser
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// compileView returns the code generated for source with opts.
+func compileView(t *testing.T, source string, opts Options) string {
+	t.Helper()
+	output, err := Compile(source, opts)
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	return strings.TrimSuffix(output.Result, "\r")
+}
+
+// compileError returns the error generating source with opts.
+func compileError(t *testing.T, source string, opts Options) error {
+	t.Helper()
+	output, err := Compile(source, opts)
+	if err == nil {
+		t.Fatalf("%s: got %s, want an error", source, output.Result)
+	}
+	return err
+}
+
+// TestNameCase checks that the generated code keeps the case of component
+// names and SVG attributes.
+func TestNameCase(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<dIV><UserCard userName="a" /></dIV>`,
+			`(*Elem).New(nil, "div").Child(UserCard.View(cx, UserCardProps{UserName: "a"}))`},
+		{`<svg viewBox="0 0 1 1"></svg>`,
+			`(*Elem).NewNS(nil, "http://www.w3.org/2000/svg", "svg").Attr("viewBox", "0 0 1 1")`},
+		{`<my-Element aria-Label="a"></my-Element>`,
+			`(*Elem).New(nil, "my-Element").Attr("aria-Label", "a")`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestComponentChildren(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<Card></Card>`, `Card.View(cx)`},
+		{`<Card title="T"><p>Hi</p>{ x }</Card>`,
+			`Card.View(cx, CardProps{Title: "T", Children: func(e *Elem) *Elem { return e.Child((*Elem).New(nil, "p").Text(` + "`Hi`" + `)).DynText(cx, func() string { v := any(x); if f, ok := v.(func() string); ok { return f() }; return fmt.Sprintf("%v", v) }) }})`},
+		{`<Card><slot:header>Head</slot:header><slot:foot-note><b></b></slot:foot-note></Card>`,
+			`Card.View(cx, CardProps{Header: func(e *Elem) *Elem { return e.Text(` + "`Head`" + `) }, FootNote: func(e *Elem) *Elem { return e.Child((*Elem).New(nil, "b")) }})`},
+		{`<div><Card><Card><p></p></Card></Card></div>`,
+			`(*Elem).New(nil, "div").Child(Card.View(cx, CardProps{Children: func(e *Elem) *Elem { return e.Child(Card.View(cx, CardProps{Children: func(e *Elem) *Elem { return e.Child((*Elem).New(nil, "p")) }})) }}))`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, test.source, Options{})
+		if got != test.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestComponentErrors(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<Card><slot:a></slot:a><slot:a></slot:a></Card>`, "<Card> sets the A prop more than once"},
+		{`<Card children="x"><p></p></Card>`, "<Card> sets the Children prop more than once"},
+		{`<Card label="a" label={ b } />`, "<Card> sets the Label prop more than once"},
+		{`<Card 08 />`, `<Card> cannot set the "08" prop, it is not a Go field name`},
+		{`<Card><slot:-></slot:-></Card>`, `<Card> cannot set the "" prop, it is not a Go field name`},
+		{`<div><slot:a></slot:a></div>`, "<slot:a> must be a direct child of a component"},
+		{`<div><A! /></div>`, "<A!> is not a component, its name is not a Go identifier"},
+		{`<Card class:dark={ d }><p></p></Card>`, "class:dark is not supported on components"},
+		{`<Card style:color={ c } />`, "style:color is not supported on components"},
+		{`<Card bind:value={ v } />`, "bind:value is not supported on components"},
+		{`<Card on:click={ f }><p></p></Card>`, "on:click is not supported on components"},
+	}
+	for _, test := range tests {
+		for _, backend := range []Backend{GoptosBackend{}, HTMLBackend{}} {
+			var err = compileError(t, test.source, Options{Backend: backend})
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("%s: got %v, want %s", test.source, err, test.want)
+			}
+		}
+	}
+}
+
+// TestValueErrors checks that attributes and code blocks without a Go
+// expression are errors at their position rather than code that does not
+// compile.
+func TestValueErrors(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`<div class:dark></div>`, "1:6: class:dark has no Go expression"},
+		{`<div style:color={ }></div>`, "1:6: style:color has no Go expression"},
+		{`<div on:click></div>`, "1:6: on:click has no Go expression"},
+		{`<div bind:value></div>`, "1:6: bind:value has no Go expression"},
+		{`<div href={}></div>`, "1:6: href has no Go expression"},
+		{`<div {...}></div>`, "{...} has no Go expression"},
+		{`<Card title={} />`, "1:7: title has no Go expression"},
+		{`<div><p>{ }</p></div>`, "1:9: {} has no Go expression"},
+	}
+	for _, test := range tests {
+		for _, backend := range []Backend{GoptosBackend{}, HTMLBackend{}} {
+			var err = compileError(t, test.source, Options{Backend: backend})
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("%s: got %v, want %s", test.source, err, test.want)
+			}
+		}
+	}
+}
+
+// TestUnbalanced checks that reading the statement or the element of an
+// empty stack is an error of the view rather than a panic.
+func TestUnbalanced(t *testing.T) {
+	var parser = New()
+	parser.reset()
+	parser.appendToStatement("x")
+	parser.squashStatement()
+	if parser.err != errNoStatement {
+		t.Errorf("got %v, want %v", parser.err, errNoStatement)
+	}
+	parser.reset()
+	parser.openElement()
+	parser.popNodeInfo()
+	if parser.err != errNoNode {
+		t.Errorf("got %v, want %v", parser.err, errNoNode)
+	}
+	parser.reset()
+	if parser.err != nil {
+		t.Errorf("reset kept %v", parser.err)
+	}
+}
+
+const benchmarkView = `
+	<li class="item" class:done={ todo.Done } on:click={ toggle }>
+		<input type="checkbox" bind:checked={ todo.Done } />
+		<!-- the label is editable -->
+		<span title='title'>{ todo.Title } left</span>
+		<Button label="remove" />
+	</li>`
+
+// BenchmarkParseView generates ever larger views, the time and allocations
+// per byte stay the same as the view grows.
+func BenchmarkParseView(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 10000} {
+		var source = "<ul>" + strings.Repeat(benchmarkView, n) + "\n</ul>\n"
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := New().ParseView(source)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// FuzzParseView checks that generating any view, with either backend and
+// every option, returns an error rather than panicking, or the same code on
+// every run. The code of a view without Go code of its own must parse.
+func FuzzParseView(f *testing.F) {
+	for _, seed := range []string{
+		"<div></div>",
+		`<div id="a" title='b' hidden>text { code }<!-- comment --></div>`,
+		`<ul><li each={ items } key={ item.ID } class:done={ d } on:click|once={ f }>{ item }</li></ul>`,
+		`<div><Button label="ok" { ...props } if={ show } /></div>`,
+		`<form><input bind:value={ name } style:color={ c } /></form>`,
+		`<svg viewBox="0 0 1 1"><circle r="1"/></svg>`,
+		`<List><slot:item>{ x }</slot:item></List>`,
+		"<div><p></div>",
+		"<div class=\"a `b`\" data-x='\"'><p>`&amp; \\ \"</p><Card title=\"x\" open /></div>",
+	} {
+		f.Add(seed, false, false, false)
+	}
+	f.Fuzz(func(t *testing.T, source string, html bool, hydrate bool, hoist bool) {
+		var parse = func() (*Parser, error) {
+			var view = New()
+			if html {
+				view.Backend = HTMLBackend{}
+			}
+			view.Hydrate = hydrate
+			view.Hoist = hoist
+			return view, view.ParseView(source)
+		}
+		view, err := parse()
+		if err != nil {
+			return
+		}
+		again, err := parse()
+		if err != nil || again.Result != view.Result || strings.Join(again.Statics, "\n") != strings.Join(view.Statics, "\n") {
+			t.Fatalf("generated\n%s\nthen\n%s (%v)", view.Result, again.Result, err)
+		}
+		if strings.ContainsAny(source, "{}") || !utf8.ValidString(source) {
+			return
+		}
+		var code strings.Builder
+		fmt.Fprintf(&code, "package view\n\n")
+		for _, static := range view.Statics {
+			fmt.Fprintf(&code, "%s\n\n", static)
+		}
+		if html {
+			fmt.Fprintf(&code, "func render() {\n%s\n}\n", strings.TrimSuffix(view.Result, "\r"))
+		} else {
+			fmt.Fprintf(&code, "func view() *Elem {\nreturn %s\n}\n", strings.TrimSuffix(view.Result, "\r"))
+		}
+		_, err = parser.ParseFile(token.NewFileSet(), "view.go", code.String(), 0)
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, code.String())
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package stateparser
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/goptos/stateparser/ast/nodes"
+	"github.com/goptos/stateparser/lexer"
+)
+
+// isStatic reports whether node and all of its descendants are plain HTML,
+// no components, slots, DynText, dynamic attributes, events or keywords.
+func isStatic(node nodes.Node) bool {
+	if node.GetType() != nodes.StartElement || strings.HasPrefix(node.GetName(), "slot:") {
+		return false
+	}
+	for _, childNode := range node.GetChildren() {
+		switch childNode.GetType() {
+		case nodes.Attribute, nodes.ArgumentAttribute, nodes.Text, nodes.Comment, nodes.EndElement:
+		case nodes.StartElement:
+			if !isStatic(childNode) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// hasStaticChildren reports whether node has any element or text children,
+// hoisting an empty element would not save anything.
+func hasStaticChildren(node nodes.Node) bool {
+	for _, childNode := range node.GetChildren() {
+		switch childNode.GetType() {
+		case nodes.StartElement, nodes.Text:
+			return true
+		}
+	}
+	return false
+}
+
+// staticHTML serialises a static subtree, comments are dropped as they are
+// for every other element.
+func staticHTML(node nodes.Node) string {
+	var html strings.Builder
+	html.WriteString("<" + node.GetName())
+	for _, childNode := range node.GetChildren() {
+		switch childNode.GetType() {
+		case nodes.Attribute:
+			html.WriteString(fmt.Sprintf(" %s=\"%s\"",
+				childNode.GetName(),
+				strings.ReplaceAll(childNode.GetValue(), "\"", "&quot;")))
+		case nodes.ArgumentAttribute:
+			html.WriteString(" " + childNode.GetName())
+		}
+	}
+	html.WriteString(">")
+	for _, childNode := range node.GetChildren() {
+		switch childNode.GetType() {
+		case nodes.Text:
+			html.WriteString(childNode.GetData())
+		case nodes.StartElement:
+			html.WriteString(staticHTML(childNode))
+		}
+	}
+	if !lexer.IsVoidElement(node.GetName()) {
+		html.WriteString("</" + node.GetName() + ">")
+	}
+	return html.String()
+}
+
+// staticName names the declaration of a hoisted subtree after its content
+// and the StaticPrefix, identical subtrees share one declaration. A subtree
+// whose hash is taken by another one gets a numbered name.
+func (_self *Parser) staticName(html string) string {
+	var hash = fnv.New64a()
+	hash.Write([]byte(html))
+	var base = fmt.Sprintf("static%016x", hash.Sum64())
+	if _self.StaticPrefix != "" {
+		base = fmt.Sprintf("%sStatic%016x", _self.StaticPrefix, hash.Sum64())
+	}
+	var name = base
+	for i := 2; ; i++ {
+		if existing, ok := _self.staticNames[name]; !ok || existing == html {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// hoist replaces the static subtree of node with a reference to its hoisted
+// declaration. Only subtrees parsed in the HTML namespace are hoisted, as
+// the HTML is parsed without its parent, and none inside a bound select,
+// whose options the Backend may mark selected.
+func (_self *Parser) hoist(node nodes.Node) bool {
+	if !_self.Hoist || !isStatic(node) || !hasStaticChildren(node) {
+		return false
+	}
+	if _self.nodeInfo.Depth() >= 0 && (_self.top().childNamespace != "" || _self.top().selectValue != "") {
+		return false
+	}
+	var html = staticHTML(node)
+	var name = _self.staticName(html)
+	if _, ok := _self.staticNames[name]; !ok {
+		_self.staticNames[name] = html
+		_self.Statics = append(_self.Statics, _self.Backend.StaticDeclaration(name, html))
+	}
+	_self.updateNodeInfo(node)
+	_self.newStatement("%s", _self.Backend.Static(name))
+	_self.squashStatement()
+	_self.popNodeInfo()
+	return true
+}
@@ -0,0 +1,103 @@
+package stateparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHoist(t *testing.T) {
+	var output, err = Compile(`<div><p>Hi</p><p>Hi</p><p>Bye</p><b>{ x }</b></div>`, Options{Hoist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Statics) != 2 {
+		t.Fatalf("got %d statics, want 2: %v", len(output.Statics), output.Statics)
+	}
+	for _, static := range output.Statics {
+		var name = strings.Fields(static)[1]
+		if strings.Count(output.Result, name+".Clone()") == 0 {
+			t.Errorf("%s is not used in %s", name, output.Result)
+		}
+	}
+	if !strings.Contains(output.Result, `.DynText(`) {
+		t.Errorf("got %s, the dynamic <b> is hoisted", output.Result)
+	}
+}
+
+// TestStaticDeclaration checks the declaration of a hoisted subtree, the
+// runtime's (*Template).New takes its nil receiver like (*Elem).New.
+// TestHoistSelect checks that the options of a bound select are not hoisted,
+// HTMLBackend marks the selected one.
+func TestHoistSelect(t *testing.T) {
+	var got = compileView(t, `<select bind:value={ n }><option value="1">One</option></select>`,
+		Options{Backend: HTMLBackend{}, Hoist: true})
+	if !strings.Contains(got, `" selected"`) {
+		t.Errorf("got %s, want the option marked selected", got)
+	}
+}
+
+func TestStaticDeclaration(t *testing.T) {
+	var want = "var static0 = (*Template).New(nil, \"<p>Hi &amp; bye</p>\")"
+	if got := (GoptosBackend{}).StaticDeclaration("static0", "<p>Hi &amp; bye</p>"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestStaticName(t *testing.T) {
+	var parser = New()
+	var name = parser.staticName("<p>a</p>")
+	if len(name) != len("static")+16 {
+		t.Errorf("got %s, want a 64 bit hash", name)
+	}
+	parser.staticNames[name] = "<p>b</p>"
+	if got := parser.staticName("<p>a</p>"); got != name+"_2" {
+		t.Errorf("got %s for a colliding subtree, want %s_2", got, name)
+	}
+	parser.staticNames[name+"_2"] = "<p>a</p>"
+	if got := parser.staticName("<p>a</p>"); got != name+"_2" {
+		t.Errorf("got %s for a hoisted subtree, want %s_2", got, name)
+	}
+	parser.StaticPrefix = "todoView"
+	if got := parser.staticName("<p>a</p>"); !strings.HasPrefix(got, "todoViewStatic") {
+		t.Errorf("got %s, want a todoViewStatic name", got)
+	}
+}
+
+// TestStaticPrefix checks that views hoisting the same subtree into one
+// package declare it under names of their own.
+func TestStaticPrefix(t *testing.T) {
+	var source = `<div><p>Hi</p></div>`
+	var a = compileView(t, source, Options{Hoist: true, StaticPrefix: "aView"})
+	var b = compileView(t, source, Options{Hoist: true, StaticPrefix: "bView"})
+	if !strings.Contains(a, "aViewStatic") || !strings.Contains(b, "bViewStatic") {
+		t.Errorf("got %s and %s, want aViewStatic and bViewStatic", a, b)
+	}
+}
+
+// TestCharacterReferences checks that text and attributes mean the same
+// hoisted or not.
+func TestCharacterReferences(t *testing.T) {
+	var source = `<div><p title="&quot;a&quot; &amp; b">a &lt; b</p></div>`
+	var tests = []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, `(*Elem).New(nil, "div").Child((*Elem).New(nil, "p").Attr("title", "\"a\" & b").Text(` + "`a < b`" + `))`},
+		{Options{Backend: HTMLBackend{}},
+			`io.WriteString(w, "<div"); io.WriteString(w, ">"); io.WriteString(w, "<p"); io.WriteString(w, " title=\"&quot;a&quot; &amp; b\""); io.WriteString(w, ">"); io.WriteString(w, "a &lt; b"); io.WriteString(w, "</p>"); io.WriteString(w, "</div>")`},
+	}
+	for _, test := range tests {
+		var got = compileView(t, source, test.opts)
+		if got != test.want {
+			t.Errorf("got:  %s\nwant: %s", got, test.want)
+		}
+	}
+	output, err := Compile(source, Options{Hoist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = `"<div><p title=\"&quot;a&quot; &amp; b\">a &lt; b</p></div>"`
+	if len(output.Statics) != 1 || !strings.Contains(output.Statics[0], want) {
+		t.Errorf("got %v, want %s", output.Statics, want)
+	}
+}